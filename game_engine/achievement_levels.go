@@ -0,0 +1,195 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+)
+
+// AchievementProgress - прогресс игрока по достижению для отображения полосы прогресса
+type AchievementProgress struct {
+	AchievementID string  `json:"achievementId"`
+	Name          string  `json:"name"`
+	Level         int     `json:"level"`
+	MaxLevel      int     `json:"maxLevel"`
+	Current       float64 `json:"current"`
+	Target        float64 `json:"target"`
+	Ratio         float64 `json:"ratio"`
+	Completed     bool    `json:"completed"`
+}
+
+// parseAchievementLevels создает уровни достижения из секции levels конфигурации
+func parseAchievementLevels(data []interface{}) ([]AchievementLevel, error) {
+	levels := make([]AchievementLevel, 0, len(data))
+	for i, entry := range data {
+		levelMap, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid level %d", i+1)
+		}
+
+		level := AchievementLevel{
+			Level:  i + 1,
+			Target: toFloat(levelMap["target"]),
+		}
+		if number, ok := levelMap["level"].(int); ok {
+			level.Level = number
+		}
+		if condition, ok := levelMap["condition"].(string); ok {
+			level.Condition = condition
+		}
+		if rewards, ok := levelMap["rewards"].(map[string]interface{}); ok {
+			level.Rewards = make(map[string]float64, len(rewards))
+			for resource, value := range rewards {
+				level.Rewards[resource] = toFloat(value)
+			}
+		}
+		if effects, ok := levelMap["effects"].([]interface{}); ok {
+			level.Effects = parseEffects(effects)
+		}
+		if level.Condition == "" && level.Target <= 0 {
+			return nil, fmt.Errorf("level %d needs a condition or a positive target", level.Level)
+		}
+		levels = append(levels, level)
+	}
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+	return levels, nil
+}
+
+// checkAchievementLevels повышает уровни многоуровневых достижений, пока выполняются условия
+// следующего уровня. Возвращает true, если хотя бы один уровень был получен
+func (g *Game) checkAchievementLevels(player *Player, evaluator *ExpressionEvaluator) bool {
+	advanced := false
+	for _, id := range g.ContentSystem.GetSortedIDs("achievements") {
+		achievement, err := g.ContentSystem.GetContent("achievements", id)
+		if err != nil || len(achievement.Levels) == 0 {
+			continue
+		}
+
+		for index := g.levelIndex(player, achievement); index < len(achievement.Levels); index++ {
+			level := achievement.Levels[index]
+			if !g.levelReached(evaluator, achievement, level) {
+				break
+			}
+			g.grantAchievementLevel(player, achievement, level)
+			advanced = true
+		}
+	}
+	return advanced
+}
+
+// levelIndex возвращает индекс следующего неполученного уровня достижения
+func (g *Game) levelIndex(player *Player, achievement GameItem) int {
+	current := player.GetAchievementLevel(achievement.ID)
+	for i, level := range achievement.Levels {
+		if level.Level > current {
+			return i
+		}
+	}
+	return len(achievement.Levels)
+}
+
+func (g *Game) levelReached(evaluator *ExpressionEvaluator, achievement GameItem, level AchievementLevel) bool {
+	if level.Condition != "" {
+		return g.requirementsMet(evaluator, []string{level.Condition})
+	}
+	current, err := evaluator.Evaluate(achievement.Progress)
+	if err != nil {
+		log.Printf("Error evaluating progress of %s: %v", achievement.ID, err)
+		return false
+	}
+	return current >= level.Target
+}
+
+// grantAchievementLevel сохраняет новый уровень достижения и выдает его награды
+func (g *Game) grantAchievementLevel(player *Player, achievement GameItem, level AchievementLevel) {
+	player.SetAchievementLevel(achievement.ID, level.Level)
+	if !player.GetAchievementStatus(achievement.ID) {
+		player.SetAchievement(achievement.ID)
+	}
+
+	source := fmt.Sprintf("%s:%d", achievement.ID, level.Level)
+	resources := make([]string, 0, len(level.Rewards))
+	for resource := range level.Rewards {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		player.State.Modifiers = append(player.State.Modifiers, Modifier{
+			Source: source,
+			Target: resource,
+			Stage:  StageMultiplicative,
+			Value:  level.Rewards[resource],
+		})
+	}
+	g.grantPermanentEffects(player, source, level.Effects)
+
+	player.AddLog(fmt.Sprintf("Achievement %s reached level %d", achievement.Name, level.Level))
+	g.EventSystem.Emit("AchievementLevelUp", map[string]interface{}{
+		"PlayerID":      player.ID,
+		"AchievementID": achievement.ID,
+		"Level":         level.Level,
+	})
+}
+
+// GetAchievementProgress возвращает прогресс игрока до следующего уровня достижения
+func (g *Game) GetAchievementProgress(player *Player, achievementID string) (AchievementProgress, error) {
+	achievement, err := g.ContentSystem.GetContent("achievements", achievementID)
+	if err != nil {
+		return AchievementProgress{}, err
+	}
+	return g.achievementProgress(NewExpressionEvaluator(player), player, achievement), nil
+}
+
+// GetAllAchievementProgress возвращает прогресс по всем достижениям в алфавитном порядке
+func (g *Game) GetAllAchievementProgress(player *Player) []AchievementProgress {
+	evaluator := NewExpressionEvaluator(player)
+	ids := g.ContentSystem.GetSortedIDs("achievements")
+	progress := make([]AchievementProgress, 0, len(ids))
+	for _, id := range ids {
+		achievement, err := g.ContentSystem.GetContent("achievements", id)
+		if err != nil {
+			continue
+		}
+		progress = append(progress, g.achievementProgress(evaluator, player, achievement))
+	}
+	return progress
+}
+
+func (g *Game) achievementProgress(evaluator *ExpressionEvaluator, player *Player, achievement GameItem) AchievementProgress {
+	progress := AchievementProgress{
+		AchievementID: achievement.ID,
+		Name:          achievement.Name,
+		Level:         player.GetAchievementLevel(achievement.ID),
+		MaxLevel:      len(achievement.Levels),
+	}
+
+	if len(achievement.Levels) == 0 {
+		progress.Completed = player.GetAchievementStatus(achievement.ID)
+		if progress.Completed {
+			progress.Ratio = 1
+		}
+		return progress
+	}
+
+	index := g.levelIndex(player, achievement)
+	if index >= len(achievement.Levels) {
+		progress.Completed = true
+		progress.Ratio = 1
+		index = len(achievement.Levels) - 1
+	}
+	progress.Target = achievement.Levels[index].Target
+
+	if achievement.Progress != "" {
+		current, err := evaluator.Evaluate(achievement.Progress)
+		if err != nil {
+			log.Printf("Error evaluating progress of %s: %v", achievement.ID, err)
+		}
+		progress.Current = current
+	}
+	if !progress.Completed && progress.Target > 0 {
+		progress.Ratio = math.Max(0, math.Min(1, progress.Current/progress.Target))
+	}
+	return progress
+}
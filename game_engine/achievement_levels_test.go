@@ -0,0 +1,32 @@
+package game_engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+func TestAchievementLevelGrantsRewardsAndEffects(t *testing.T) {
+	game, player := newTestGame(t)
+	player.State.Items["pan"].Amount = bignum.FromInt(1000)
+	coins := player.GetItemAmount("gold_coin")
+
+	game.checkAchievements(player)
+
+	if level := player.GetAchievementLevel("pan_collector"); level != 3 {
+		t.Fatalf("pan_collector level = %d, want 3", level)
+	}
+	if got, want := player.GetItemAmount("gold_coin"), coins.Add(bignum.FromInt(10)); !got.Eq(want) {
+		t.Errorf("gold_coin = %v, want %v after the level 3 grant effect", got, want)
+	}
+	multipliers := 0
+	for _, modifier := range player.State.Modifiers {
+		if strings.HasPrefix(modifier.Source, "pan_collector:") && modifier.Target == "gold" {
+			multipliers++
+		}
+	}
+	if multipliers != 3 {
+		t.Errorf("got %d gold multipliers from levels, want 3", multipliers)
+	}
+}
@@ -0,0 +1,99 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// achievementTick - этап тика, открывающий достижения с выполненными требованиями
+func (g *Game) achievementTick(player *Player, now time.Time, dt time.Duration) {
+	g.checkAchievements(player)
+}
+
+// checkAchievements открывает достижения, требования которых выполнены, и возвращает их ID.
+// Проверяются только еще не полученные достижения; все требования вычисляются
+// на одном снимке параметров игрока по заранее разобранным выражениям.
+// Многоуровневые достижения повышают уровень в checkAchievementLevels
+func (g *Game) checkAchievements(player *Player) []string {
+	unlocked := make([]string, 0)
+	evaluator := NewExpressionEvaluator(player)
+	for _, id := range g.ContentSystem.GetSortedIDs("achievements") {
+		if player.GetAchievementStatus(id) {
+			continue
+		}
+		achievement, err := g.ContentSystem.GetContent("achievements", id)
+		if err != nil || len(achievement.Reqs) == 0 || len(achievement.Levels) > 0 {
+			continue
+		}
+		if !g.requirementsMet(evaluator, achievement.Reqs) {
+			continue
+		}
+
+		g.unlockAchievement(player, achievement)
+		unlocked = append(unlocked, id)
+	}
+
+	leveled := g.checkAchievementLevels(player, evaluator)
+	if len(unlocked) > 0 || leveled {
+		player.RecalculateState()
+	}
+	return unlocked
+}
+
+// requirementsMet проверяет, что все выражения-требования истинны
+func (g *Game) requirementsMet(evaluator *ExpressionEvaluator, reqs []string) bool {
+	for _, req := range reqs {
+		result, err := evaluator.Evaluate(req)
+		if err != nil {
+			log.Printf("Error evaluating condition: %v", err)
+			return false
+		}
+		if result <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// unlockAchievement отмечает достижение полученным и выдает его награды
+func (g *Game) unlockAchievement(player *Player, achievement GameItem) {
+	player.SetAchievement(achievement.ID)
+	if item := player.GetItem(achievement.ID); item != nil {
+		item.Amount = bignum.One()
+	}
+
+	g.grantPermanentEffects(player, achievement.ID, achievement.Effects)
+
+	player.AddLog(fmt.Sprintf("Achievement unlocked: %s", achievement.Name))
+	g.EventSystem.Emit("AchievementUnlocked", map[string]interface{}{
+		"PlayerID":      player.ID,
+		"AchievementID": achievement.ID,
+		"Name":          achievement.Name,
+	})
+}
+
+// grantPermanentEffects выдает награду source: модификаторы производства
+// становятся постоянными, остальные эффекты применяются один раз
+func (g *Game) grantPermanentEffects(player *Player, source string, effects []Effect) {
+	for _, effect := range effects {
+		stage, ok := effectStage(effect.Type)
+		if !ok {
+			g.applyEffect(player, effect)
+			continue
+		}
+		value, err := effectValue(player, effect)
+		if err != nil {
+			log.Printf("Error evaluating %s effect of %s: %v", effect.Type, source, err)
+			continue
+		}
+		player.State.Modifiers = append(player.State.Modifiers, Modifier{
+			Source: source,
+			Target: effect.Target,
+			Stage:  stage,
+			Value:  value,
+		})
+	}
+}
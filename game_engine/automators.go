@@ -0,0 +1,292 @@
+package game_engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// AutomatorStrategy - правило, по которому автоматизатор решает, покупать ли предмет
+type AutomatorStrategy string
+
+const (
+	// StrategyCheapest покупает самый дешевый доступный предмет, пока хватает ресурсов
+	StrategyCheapest AutomatorStrategy = "cheapest"
+	// StrategyReserve покупает, оставляя Value процентов каждого ресурса от запаса на начало запуска
+	StrategyReserve AutomatorStrategy = "reserve"
+	// StrategyBelow покупает, только если цена не превышает Value процентов текущего запаса
+	StrategyBelow AutomatorStrategy = "below"
+)
+
+const (
+	// defaultAutomatorInterval используется, если у автоматизатора не задан interval
+	defaultAutomatorInterval = 10 * time.Second
+	// maxAutomatorPurchases ограничивает число покупок за один запуск автоматизатора
+	maxAutomatorPurchases = 100
+)
+
+// Automator - автоматизатор из категории automators. Открывается покупкой или
+// выполнением требований и раз в Interval покупает предметы из Targets (ID или категории)
+type Automator struct {
+	ID       string
+	Name     string
+	Interval time.Duration
+	Targets  []string
+	Reqs     []string
+	Defaults AutomatorSettings
+}
+
+// AutomatorSettings - настройки автоматизатора игрока
+type AutomatorSettings struct {
+	Enabled  bool              `json:"enabled"`
+	Strategy AutomatorStrategy `json:"strategy"`
+	Value    float64           `json:"value"`
+	LastRun  time.Time         `json:"lastRun"`
+}
+
+// AutomatorStatus описывает автоматизатор для интерфейса
+type AutomatorStatus struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Unlocked bool              `json:"unlocked"`
+	Interval time.Duration     `json:"interval"`
+	Targets  []string          `json:"targets"`
+	Settings AutomatorSettings `json:"settings"`
+}
+
+// parseAutomators создает автоматизаторы из категории automators
+func parseAutomators(items map[string]GameItem) (map[string]Automator, error) {
+	automators := make(map[string]Automator, len(items))
+	for id, item := range items {
+		automator := Automator{
+			ID:       id,
+			Name:     item.Name,
+			Interval: defaultAutomatorInterval,
+			Targets:  toStringSlice(item.Properties["targets"]),
+			Reqs:     item.Reqs,
+			Defaults: AutomatorSettings{Enabled: true, Strategy: StrategyCheapest},
+		}
+		if interval := toFloat(item.Properties["interval"]); interval > 0 {
+			automator.Interval = time.Duration(interval * float64(time.Second))
+		}
+		if len(automator.Targets) == 0 {
+			return nil, fmt.Errorf("automator %s has no targets", id)
+		}
+		if strategy, ok := item.Properties["strategy"].(string); ok {
+			automator.Defaults.Strategy = AutomatorStrategy(strategy)
+		}
+		automator.Defaults.Value = toFloat(item.Properties["value"])
+		if err := validateAutomatorSettings(automator.Defaults); err != nil {
+			return nil, fmt.Errorf("automator %s: %w", id, err)
+		}
+		automators[id] = automator
+	}
+	return automators, nil
+}
+
+// validateAutomatorSettings проверяет стратегию и ее параметр
+func validateAutomatorSettings(settings AutomatorSettings) error {
+	switch settings.Strategy {
+	case StrategyCheapest:
+		return nil
+	case StrategyReserve, StrategyBelow:
+		if settings.Value < 0 || settings.Value > 100 {
+			return fmt.Errorf("%s percentage must be between 0 and 100: %v", settings.Strategy, settings.Value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown automator strategy: %s", settings.Strategy)
+	}
+}
+
+// GetAutomator возвращает автоматизатор по ID
+func (cs *ContentSystem) GetAutomator(id string) (Automator, error) {
+	automator, ok := cs.automators[id]
+	if !ok {
+		return Automator{}, fmt.Errorf("automator not found: %s", id)
+	}
+	return automator, nil
+}
+
+// automatorUnlocked проверяет, открыт ли автоматизатор игроку
+func (g *Game) automatorUnlocked(player *Player, evaluator *ExpressionEvaluator, automator Automator) bool {
+	if player.GetItemAmount(automator.ID).Sign() > 0 {
+		return true
+	}
+	return len(automator.Reqs) > 0 && g.requirementsMet(evaluator, automator.Reqs)
+}
+
+// automatorSettings возвращает настройки игрока или значения по умолчанию из конфигурации
+func (p *Player) automatorSettings(automator Automator) AutomatorSettings {
+	if settings, ok := p.State.Automators[automator.ID]; ok {
+		return settings
+	}
+	return automator.Defaults
+}
+
+// automatorTick - этап тика, запускающий включенные автоматизаторы, чей интервал истек
+func (g *Game) automatorTick(player *Player, now time.Time, dt time.Duration) {
+	evaluator := NewExpressionEvaluator(player)
+	for _, id := range g.ContentSystem.GetSortedIDs("automators") {
+		automator, err := g.ContentSystem.GetAutomator(id)
+		if err != nil || !g.automatorUnlocked(player, evaluator, automator) {
+			continue
+		}
+		settings := player.automatorSettings(automator)
+		if !settings.Enabled || now.Sub(settings.LastRun) < automator.Interval {
+			continue
+		}
+
+		g.runAutomator(player, automator, settings)
+		settings.LastRun = now
+		player.State.Automators[id] = settings
+	}
+}
+
+// runAutomator покупает предметы по стратегии автоматизатора, каждый раз выбирая
+// самый дешевый относительно запасов игрока
+func (g *Game) runAutomator(player *Player, automator Automator, settings AutomatorSettings) int {
+	targets := g.automatorTargets(automator)
+	reserve := make(map[string]bignum.Number)
+	if settings.Strategy == StrategyReserve {
+		for _, item := range player.State.Items {
+			if item.Type == "resources" {
+				reserve[item.ID] = item.Amount.MulFloat(settings.Value / 100)
+			}
+		}
+	}
+
+	bought := 0
+	for bought < maxAutomatorPurchases {
+		best, bestShare := "", 0.0
+		// Один снимок параметров на все кандидаты: до покупки состояние игрока не меняется
+		evaluator := NewExpressionEvaluator(player)
+		for _, id := range targets {
+			quote, err := g.quoteBuy(player, evaluator, id, PurchaseRequest{Mode: PurchaseCount, Count: 1})
+			if err != nil || !quote.Affordable {
+				continue
+			}
+			share, ok := automatorAllows(player, settings, reserve, quote.Cost)
+			if ok && (best == "" || share < bestShare) {
+				best, bestShare = id, share
+			}
+		}
+		if best == "" || g.Buy(player, best) != nil {
+			break
+		}
+		bought++
+	}
+
+	if bought > 0 {
+		g.EventSystem.Emit("AutomatorRun", map[string]interface{}{
+			"PlayerID":    player.ID,
+			"AutomatorID": automator.ID,
+			"Bought":      bought,
+		})
+	}
+	return bought
+}
+
+// automatorAllows проверяет стоимость по стратегии и возвращает наибольшую долю запаса,
+// которую забирает покупка - по ней выбирается самый дешевый предмет
+func automatorAllows(player *Player, settings AutomatorSettings, reserve map[string]bignum.Number, cost map[string]bignum.Number) (float64, bool) {
+	share := 0.0
+	for resource, amount := range cost {
+		stock := player.GetItemAmount(resource)
+		if stock.Sign() <= 0 {
+			return 0, false
+		}
+		resourceShare := amount.Div(stock).Float64()
+		if resourceShare > share {
+			share = resourceShare
+		}
+
+		switch settings.Strategy {
+		case StrategyReserve:
+			if stock.Sub(amount).Lt(reserve[resource]) {
+				return 0, false
+			}
+		case StrategyBelow:
+			if resourceShare*100 > settings.Value {
+				return 0, false
+			}
+		}
+	}
+	return share, true
+}
+
+// automatorTargets раскрывает категории в списке целей автоматизатора
+func (g *Game) automatorTargets(automator Automator) []string {
+	targets := make([]string, 0, len(automator.Targets))
+	for _, target := range automator.Targets {
+		if ids := g.ContentSystem.GetSortedIDs(target); len(ids) > 0 {
+			targets = append(targets, ids...)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// ConfigureAutomator меняет настройки открытого автоматизатора игрока
+func (g *Game) ConfigureAutomator(player *Player, automatorID string, configure func(*AutomatorSettings)) error {
+	automator, err := g.ContentSystem.GetAutomator(automatorID)
+	if err != nil {
+		return err
+	}
+	if !g.automatorUnlocked(player, NewExpressionEvaluator(player), automator) {
+		return fmt.Errorf("automator is locked: %s", automatorID)
+	}
+
+	settings := player.automatorSettings(automator)
+	configure(&settings)
+	if err := validateAutomatorSettings(settings); err != nil {
+		return err
+	}
+	player.State.Automators[automatorID] = settings
+
+	g.EventSystem.Emit("AutomatorConfigured", map[string]interface{}{
+		"PlayerID":    player.ID,
+		"AutomatorID": automatorID,
+		"Settings":    settings,
+	})
+	return nil
+}
+
+// SetAutomatorEnabled включает или выключает автоматизатор
+func (g *Game) SetAutomatorEnabled(player *Player, automatorID string, enabled bool) error {
+	return g.ConfigureAutomator(player, automatorID, func(settings *AutomatorSettings) {
+		settings.Enabled = enabled
+	})
+}
+
+// SetAutomatorStrategy задает стратегию автоматизатора и ее параметр в процентах
+func (g *Game) SetAutomatorStrategy(player *Player, automatorID string, strategy AutomatorStrategy, value float64) error {
+	return g.ConfigureAutomator(player, automatorID, func(settings *AutomatorSettings) {
+		settings.Strategy = strategy
+		settings.Value = value
+	})
+}
+
+// GetAutomators возвращает состояние всех автоматизаторов игрока
+func (g *Game) GetAutomators(player *Player) []AutomatorStatus {
+	evaluator := NewExpressionEvaluator(player)
+	ids := g.ContentSystem.GetSortedIDs("automators")
+	statuses := make([]AutomatorStatus, 0, len(ids))
+	for _, id := range ids {
+		automator, err := g.ContentSystem.GetAutomator(id)
+		if err != nil {
+			continue
+		}
+		statuses = append(statuses, AutomatorStatus{
+			ID:       id,
+			Name:     automator.Name,
+			Unlocked: g.automatorUnlocked(player, evaluator, automator),
+			Interval: automator.Interval,
+			Targets:  g.automatorTargets(automator),
+			Settings: player.automatorSettings(automator),
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,124 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ItemVisibility - насколько предмет доступен игроку
+type ItemVisibility string
+
+const (
+	// VisibilityHidden - предмет не показывается игроку
+	VisibilityHidden ItemVisibility = "hidden"
+	// VisibilityLocked - предмет виден, но его требования не выполнены
+	VisibilityLocked ItemVisibility = "locked"
+	// VisibilityPurchasable - предмет можно купить, если хватает ресурсов
+	VisibilityPurchasable ItemVisibility = "purchasable"
+)
+
+// RequirementsError возвращается при покупке предмета, требования которого не выполнены
+type RequirementsError struct {
+	ItemID string
+	Unmet  []string
+}
+
+func (e *RequirementsError) Error() string {
+	return fmt.Sprintf("requirements not met for %s: %s", e.ItemID, strings.Join(e.Unmet, ", "))
+}
+
+// ItemAvailability - доступность предмета игроку и невыполненные требования
+type ItemAvailability struct {
+	ItemID     string         `json:"itemId"`
+	Name       string         `json:"name"`
+	Category   string         `json:"category"`
+	Visibility ItemVisibility `json:"visibility"`
+	Unmet      []string       `json:"unmet,omitempty"`
+}
+
+// unmetRequirements возвращает выражения-требования, которые не выполнены
+func unmetRequirements(evaluator *ExpressionEvaluator, reqs []string) []string {
+	unmet := make([]string, 0)
+	for _, req := range reqs {
+		result, err := evaluator.Evaluate(req)
+		if err != nil {
+			log.Printf("Error evaluating condition: %v", err)
+		}
+		if err != nil || result <= 0 {
+			unmet = append(unmet, req)
+		}
+	}
+	return unmet
+}
+
+// itemAvailability определяет доступность предмета. Купленные предметы остаются
+// доступными, даже если их требования перестали выполняться. Предметы с hidden: true
+// скрыты, пока требования не выполнены, остальные видны как закрытые
+func (g *Game) itemAvailability(player *Player, evaluator *ExpressionEvaluator, item GameItem) ItemAvailability {
+	availability := ItemAvailability{
+		ItemID:     item.ID,
+		Name:       item.Name,
+		Category:   item.Type,
+		Visibility: VisibilityPurchasable,
+	}
+	if !g.ContentSystem.IsAvailable(item.ID) {
+		availability.Visibility = VisibilityHidden
+		return availability
+	}
+	if player.GetItemAmount(item.ID).Sign() > 0 {
+		return availability
+	}
+
+	availability.Unmet = unmetRequirements(evaluator, item.Reqs)
+	if !player.milestoneUnlocked(item.ID) {
+		names := make([]string, 0)
+		for _, id := range g.ContentSystem.unlocks[item.ID] {
+			milestone, _ := g.ContentSystem.GetMilestone(id)
+			names = append(names, milestone.Name)
+		}
+		availability.Unmet = append(availability.Unmet, "milestone "+strings.Join(names, " or "))
+	}
+
+	switch {
+	case len(availability.Unmet) == 0:
+		availability.Unmet = nil
+	case item.Hidden:
+		availability.Visibility = VisibilityHidden
+	default:
+		availability.Visibility = VisibilityLocked
+	}
+	return availability
+}
+
+// GetItemAvailability возвращает доступность предмета игроку
+func (g *Game) GetItemAvailability(player *Player, itemID string) (ItemAvailability, error) {
+	item, ok := g.ContentSystem.GetItem(itemID)
+	if !ok {
+		return ItemAvailability{}, fmt.Errorf("item not found: %s", itemID)
+	}
+	return g.itemAvailability(player, NewExpressionEvaluator(player), item), nil
+}
+
+// GetAvailableItems возвращает видимые игроку покупаемые предметы по категориям
+// в алфавитном порядке. Скрытые предметы в список не попадают
+func (g *Game) GetAvailableItems(player *Player) map[string][]ItemAvailability {
+	evaluator := NewExpressionEvaluator(player)
+	available := make(map[string][]ItemAvailability)
+	for _, category := range g.ContentSystem.GetCategories() {
+		if category == "prestige" || category == "research" {
+			continue
+		}
+		for _, id := range g.ContentSystem.GetSortedIDs(category) {
+			item, _ := g.ContentSystem.GetItem(id)
+			if len(item.Cost) == 0 {
+				continue
+			}
+			availability := g.itemAvailability(player, evaluator, item)
+			if availability.Visibility != VisibilityHidden {
+				available[category] = append(available[category], availability)
+			}
+		}
+	}
+	return available
+}
@@ -0,0 +1,380 @@
+package bignum
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// smallLimit - числа меньше этого значения по модулю хранятся как обычный float64
+	// (с нулевой экспонентой), чтобы целочисленная арифметика оставалась точной
+	smallLimit = 1e15
+	// maxPrecision - разница порядков, после которой меньшее слагаемое не влияет на сумму
+	maxPrecision = 17
+)
+
+// Number - число произвольной величины в виде мантиссы и десятичной экспоненты:
+// Mantissa * 10^Exponent. Небольшие числа хранятся с нулевой экспонентой,
+// большие - с мантиссой, нормализованной в диапазон [1, 10).
+type Number struct {
+	mantissa float64
+	exponent int64
+}
+
+// New создает число mantissa * 10^exponent
+func New(mantissa float64, exponent int64) Number {
+	return normalize(mantissa, exponent)
+}
+
+// FromFloat создает число из float64
+func FromFloat(value float64) Number {
+	return normalize(value, 0)
+}
+
+// FromInt создает число из int
+func FromInt(value int) Number {
+	return normalize(float64(value), 0)
+}
+
+// Zero возвращает ноль
+func Zero() Number {
+	return Number{}
+}
+
+// One возвращает единицу
+func One() Number {
+	return Number{mantissa: 1}
+}
+
+// Parse разбирает число из строки вида "123.5", "1.5e400" или "-2E-12"
+func Parse(s string) (Number, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Number{}, fmt.Errorf("empty number")
+	}
+
+	idx := strings.LastIndexAny(s, "eE")
+	if idx == -1 {
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return Number{}, fmt.Errorf("invalid number %q: %w", s, err)
+		}
+		return FromFloat(value), nil
+	}
+
+	mantissa, err := strconv.ParseFloat(s[:idx], 64)
+	if err != nil {
+		return Number{}, fmt.Errorf("invalid mantissa in %q: %w", s, err)
+	}
+	exponent, err := strconv.ParseInt(s[idx+1:], 10, 64)
+	if err != nil {
+		return Number{}, fmt.Errorf("invalid exponent in %q: %w", s, err)
+	}
+	return normalize(mantissa, exponent), nil
+}
+
+// MustParse разбирает число из строки и паникует при ошибке
+func MustParse(s string) Number {
+	n, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func normalize(mantissa float64, exponent int64) Number {
+	if mantissa == 0 || math.IsNaN(mantissa) {
+		return Number{}
+	}
+	if math.IsInf(mantissa, 0) {
+		return Number{mantissa: math.Copysign(math.MaxFloat64/1e308, mantissa), exponent: 308}
+	}
+	if exponent == 0 && math.Abs(mantissa) < smallLimit {
+		return Number{mantissa: mantissa}
+	}
+
+	n := Number{mantissa: mantissa, exponent: exponent}.normalized()
+	if n.exponent < 15 && n.exponent > -300 {
+		if value := n.mantissa * math.Pow10(int(n.exponent)); math.Abs(value) < smallLimit {
+			return Number{mantissa: value}
+		}
+	}
+	return n
+}
+
+// normalized приводит мантиссу к диапазону [1, 10)
+func (n Number) normalized() Number {
+	shift := math.Floor(math.Log10(math.Abs(n.mantissa)))
+	n.mantissa /= math.Pow(10, shift)
+	n.exponent += int64(shift)
+	if math.Abs(n.mantissa) >= 10 {
+		n.mantissa /= 10
+		n.exponent++
+	} else if math.Abs(n.mantissa) < 1 {
+		n.mantissa *= 10
+		n.exponent--
+	}
+	return n
+}
+
+// Mantissa возвращает мантиссу в диапазоне [1, 10) (0 для нуля)
+func (n Number) Mantissa() float64 {
+	if n.IsZero() {
+		return 0
+	}
+	return n.normalized().mantissa
+}
+
+// Exponent возвращает десятичный порядок числа
+func (n Number) Exponent() int64 {
+	if n.IsZero() {
+		return 0
+	}
+	return n.normalized().exponent
+}
+
+// IsZero проверяет, равно ли число нулю
+func (n Number) IsZero() bool {
+	return n.mantissa == 0
+}
+
+// Sign возвращает -1, 0 или 1 в зависимости от знака числа
+func (n Number) Sign() int {
+	switch {
+	case n.mantissa > 0:
+		return 1
+	case n.mantissa < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Neg возвращает -n. Ноль остается нулем без знака: иначе -0 печатался бы как "-0"
+func (n Number) Neg() Number {
+	if n.IsZero() {
+		return n
+	}
+	return Number{mantissa: -n.mantissa, exponent: n.exponent}
+}
+
+// Abs возвращает |n|
+func (n Number) Abs() Number {
+	return Number{mantissa: math.Abs(n.mantissa), exponent: n.exponent}
+}
+
+// Add возвращает n + other
+func (n Number) Add(other Number) Number {
+	if n.IsZero() {
+		return other
+	}
+	if other.IsZero() {
+		return n
+	}
+	if n.exponent == 0 && other.exponent == 0 {
+		return normalize(n.mantissa+other.mantissa, 0)
+	}
+	// Рядом с границей smallLimit слагаемые точно представимы в float64: сумма
+	// через мантиссы дала бы ошибку округления при возврате в диапазон малых чисел
+	if n.Exponent() < 16 && other.Exponent() < 16 {
+		return normalize(n.Float64()+other.Float64(), 0)
+	}
+
+	diff := n.Exponent() - other.Exponent()
+	if diff > maxPrecision {
+		return n
+	}
+	if diff < -maxPrecision {
+		return other
+	}
+	return normalize(n.mantissa+other.mantissa*math.Pow10(int(other.exponent-n.exponent)), n.exponent)
+}
+
+// Sub возвращает n - other
+func (n Number) Sub(other Number) Number {
+	return n.Add(other.Neg())
+}
+
+// Mul возвращает n * other
+func (n Number) Mul(other Number) Number {
+	return normalize(n.mantissa*other.mantissa, n.exponent+other.exponent)
+}
+
+// MulFloat возвращает n * factor
+func (n Number) MulFloat(factor float64) Number {
+	return n.Mul(FromFloat(factor))
+}
+
+// Div возвращает n / other. Деление на ноль дает ноль
+func (n Number) Div(other Number) Number {
+	if other.IsZero() {
+		return Number{}
+	}
+	return normalize(n.mantissa/other.mantissa, n.exponent-other.exponent)
+}
+
+// Pow возвращает n в степени power
+func (n Number) Pow(power float64) Number {
+	if power == 0 {
+		return One()
+	}
+	if n.IsZero() {
+		return Number{}
+	}
+	if n.exponent == 0 {
+		if value := math.Pow(n.mantissa, power); !math.IsInf(value, 0) && !math.IsNaN(value) {
+			return FromFloat(value)
+		}
+	}
+
+	sign := 1.0
+	if n.mantissa < 0 {
+		if power != math.Trunc(power) {
+			return Number{}
+		}
+		if math.Mod(power, 2) != 0 {
+			sign = -1
+		}
+	}
+
+	log := n.Log10() * power
+	exponent := math.Floor(log)
+	return normalize(sign*math.Pow(10, log-exponent), int64(exponent))
+}
+
+// Log10 возвращает десятичный логарифм |n|
+func (n Number) Log10() float64 {
+	if n.IsZero() {
+		return math.Inf(-1)
+	}
+	return math.Log10(math.Abs(n.mantissa)) + float64(n.exponent)
+}
+
+// Floor округляет число вниз до целого
+func (n Number) Floor() Number {
+	if n.exponent == 0 {
+		return FromFloat(math.Floor(n.mantissa))
+	}
+	if n.Exponent() >= maxPrecision {
+		return n
+	}
+	return FromFloat(math.Floor(n.Float64()))
+}
+
+// Ceil округляет число вверх до целого
+func (n Number) Ceil() Number {
+	if n.exponent == 0 {
+		return FromFloat(math.Ceil(n.mantissa))
+	}
+	if n.Exponent() >= maxPrecision {
+		return n
+	}
+	return FromFloat(math.Ceil(n.Float64()))
+}
+
+// Cmp сравнивает числа: -1 если n < other, 0 если равны, 1 если n > other
+func (n Number) Cmp(other Number) int {
+	return n.Sub(other).Sign()
+}
+
+// Eq проверяет n == other
+func (n Number) Eq(other Number) bool {
+	return n.Cmp(other) == 0
+}
+
+// Lt проверяет n < other
+func (n Number) Lt(other Number) bool {
+	return n.Cmp(other) < 0
+}
+
+// Lte проверяет n <= other
+func (n Number) Lte(other Number) bool {
+	return n.Cmp(other) <= 0
+}
+
+// Gt проверяет n > other
+func (n Number) Gt(other Number) bool {
+	return n.Cmp(other) > 0
+}
+
+// Gte проверяет n >= other
+func (n Number) Gte(other Number) bool {
+	return n.Cmp(other) >= 0
+}
+
+// Max возвращает большее из чисел
+func Max(a, b Number) Number {
+	if a.Gte(b) {
+		return a
+	}
+	return b
+}
+
+// Min возвращает меньшее из чисел
+func Min(a, b Number) Number {
+	if a.Lte(b) {
+		return a
+	}
+	return b
+}
+
+// Float64 преобразует число в float64. Слишком большие числа дают ±Inf
+func (n Number) Float64() float64 {
+	if n.exponent == 0 {
+		return n.mantissa
+	}
+	return n.mantissa * math.Pow10(int(n.exponent))
+}
+
+// Int преобразует число в int с отбрасыванием дробной части и насыщением на границах int
+func (n Number) Int() int {
+	value := n.Float64()
+	switch {
+	case value >= math.MaxInt:
+		return math.MaxInt
+	case value <= math.MinInt:
+		return math.MinInt
+	default:
+		return int(value)
+	}
+}
+
+// String возвращает представление числа без потери точности
+func (n Number) String() string {
+	if n.exponent == 0 {
+		return strconv.FormatFloat(n.mantissa, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(n.mantissa, 'f', -1, 64) + "e" + strconv.FormatInt(n.exponent, 10)
+}
+
+// MarshalJSON сохраняет небольшие числа как JSON-числа, а большие - как строки вида "1.5e400"
+func (n Number) MarshalJSON() ([]byte, error) {
+	if n.exponent == 0 {
+		return []byte(strconv.FormatFloat(n.mantissa, 'g', -1, 64)), nil
+	}
+	return json.Marshal(n.String())
+}
+
+// UnmarshalJSON читает число из JSON-числа или строки
+func (n *Number) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		*n = Number{}
+		return nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(raw)
+	if err != nil {
+		return err
+	}
+	*n = parsed
+	return nil
+}
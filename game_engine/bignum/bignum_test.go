@@ -0,0 +1,185 @@
+package bignum
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// approxEqual сравнивает числа с относительной погрешностью float64
+func approxEqual(a, b Number) bool {
+	if b.IsZero() {
+		return a.IsZero()
+	}
+	return a.Sub(b).Div(b).Abs().Float64() < 1e-12
+}
+
+func TestAddSubAcrossExponents(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Number
+		sum  Number
+		diff Number
+	}{
+		{"small", FromFloat(1.5), FromFloat(2.25), FromFloat(3.75), FromFloat(-0.75)},
+		{"same large exponent", New(1, 20), New(1, 20), New(2, 20), Zero()},
+		{"adjacent exponents", New(1, 300), New(1, 299), New(1.1, 300), New(9, 299)},
+		{"beyond float64", New(5, 400), New(5, 400), New(1, 401), Zero()},
+		{"large and small", New(1, 20), FromInt(1), New(1.00000000000000000001, 20), New(1, 20)},
+		{"precision cutoff", New(1, 400), FromInt(1), New(1, 400), New(1, 400)},
+		{"opposite signs", New(-5, 20), New(5, 20), Zero(), New(-1, 21)},
+		{"negative beyond float64", New(-2, 500), New(3, 499), New(-1.7, 500), New(-2.3, 500)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Add(tt.b); !approxEqual(got, tt.sum) {
+				t.Errorf("%v + %v = %v, want %v", tt.a, tt.b, got, tt.sum)
+			}
+			if got := tt.a.Sub(tt.b); !approxEqual(got, tt.diff) {
+				t.Errorf("%v - %v = %v, want %v", tt.a, tt.b, got, tt.diff)
+			}
+		})
+	}
+}
+
+func TestMulDivAcrossExponents(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     Number
+		product  Number
+		quotient Number
+	}{
+		{"small", FromInt(6), FromInt(3), FromInt(18), FromInt(2)},
+		{"into big range", New(1, 200), New(1, 200), New(1, 400), FromInt(1)},
+		{"big by small", New(3, 400), FromInt(3), New(9, 400), New(1, 400)},
+		{"back to small range", New(1, 20), New(1, -10), New(1, 10), New(1, 30)},
+		{"negative", New(-2, 300), New(4, 300), New(-8, 600), FromFloat(-0.5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Mul(tt.b); !approxEqual(got, tt.product) {
+				t.Errorf("%v * %v = %v, want %v", tt.a, tt.b, got, tt.product)
+			}
+			if got := tt.a.Div(tt.b); !approxEqual(got, tt.quotient) {
+				t.Errorf("%v / %v = %v, want %v", tt.a, tt.b, got, tt.quotient)
+			}
+		})
+	}
+
+	if got := New(1, 400).Div(Zero()); !got.IsZero() {
+		t.Errorf("division by zero = %v, want 0", got)
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		a, b Number
+		want int
+	}{
+		{FromInt(1), FromInt(2), -1},
+		{FromInt(2), FromInt(1), 1},
+		{New(1, 400), New(1, 400), 0},
+		{New(1, 400), New(9, 399), 1},
+		{New(9, 399), New(1, 400), -1},
+		{New(1, 400), FromFloat(1e300), 1},
+		{New(-1, 400), FromInt(1), -1},
+		{New(-1, 400), New(-1, 399), -1},
+		{Zero(), New(-1, 400), 1},
+		{Zero(), Zero(), 0},
+		{FromFloat(1e15), New(1, 15), 0},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Cmp(tt.b); got != tt.want {
+			t.Errorf("Cmp(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizationAtSmallLimit(t *testing.T) {
+	below := FromFloat(999999999999999)
+	if got := below.String(); got != "999999999999999" {
+		t.Errorf("below limit = %s, want plain digits", got)
+	}
+	if got := FromFloat(1e15).String(); got != "1e15" {
+		t.Errorf("at limit = %s, want 1e15", got)
+	}
+
+	crossed := below.Add(One())
+	if got := crossed.String(); got != "1e15" {
+		t.Errorf("crossing the limit = %s, want 1e15", got)
+	}
+	if got := crossed.Sub(One()).String(); got != "999999999999999" {
+		t.Errorf("crossing back = %s, want 999999999999999", got)
+	}
+	if got := New(9.99, 14).String(); got != "999000000000000" {
+		t.Errorf("New(9.99, 14) = %s, want plain digits", got)
+	}
+	if crossed.Mantissa() != 1 || crossed.Exponent() != 15 {
+		t.Errorf("1e15 = %v * 10^%d, want 1 * 10^15", crossed.Mantissa(), crossed.Exponent())
+	}
+	if below.Exponent() != 14 {
+		t.Errorf("exponent of %v = %d, want 14", below, below.Exponent())
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		value   Number
+		encoded string
+	}{
+		{FromFloat(123.5), `123.5`},
+		{FromInt(-42), `-42`},
+		{Zero(), `0`},
+		{New(1.5, 400), `"1.5e400"`},
+		{New(-2, 20), `"-2e20"`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.value)
+		if err != nil {
+			t.Fatalf("marshal %v: %v", tt.value, err)
+		}
+		if string(data) != tt.encoded {
+			t.Errorf("marshal %v = %s, want %s", tt.value, data, tt.encoded)
+		}
+
+		var decoded Number
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("unmarshal %s: %v", data, err)
+		}
+		if !decoded.Eq(tt.value) {
+			t.Errorf("round trip of %v = %v", tt.value, decoded)
+		}
+	}
+
+	// Числа, сохраненные как строки, читаются и в малом диапазоне
+	var decoded Number
+	if err := json.Unmarshal([]byte(`"250"`), &decoded); err != nil || !decoded.Eq(FromInt(250)) {
+		t.Errorf(`unmarshal "250" = %v, %v`, decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`null`), &decoded); err != nil || !decoded.IsZero() {
+		t.Errorf("unmarshal null = %v, %v", decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`"lots"`), &decoded); err == nil {
+		t.Error("unmarshal of an invalid string succeeded")
+	}
+}
+
+func TestNegOfZeroHasNoSign(t *testing.T) {
+	for name, zero := range map[string]Number{
+		"neg zero":    Zero().Neg(),
+		"zero - zero": Zero().Sub(Zero()),
+		"x - x":       FromInt(5).Sub(FromInt(5)),
+		"big - big":   New(3, 400).Sub(New(3, 400)),
+		"x + (-x)":    FromFloat(0.5).Add(FromFloat(0.5).Neg()),
+	} {
+		if math.Signbit(zero.Float64()) {
+			t.Errorf("%s is negative zero", name)
+		}
+		if got := zero.String(); got != "0" {
+			t.Errorf("%s = %q, want \"0\"", name, got)
+		}
+		if zero.Sign() != 0 || zero.Cmp(Zero()) != 0 || Zero().Cmp(zero) != 0 {
+			t.Errorf("%s does not compare equal to zero", name)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// BoostStacking - правило, по которому повторное получение ускорения сочетается с уже активным
+type BoostStacking string
+
+const (
+	// BoostRefresh заменяет активное ускорение новым и перезапускает таймер
+	BoostRefresh BoostStacking = "refresh"
+	// BoostExtend продлевает активное ускорение на длительность нового
+	BoostExtend BoostStacking = "extend"
+	// BoostStack добавляет независимый экземпляр, бонусы экземпляров складываются: x2 и x2 дают x3
+	BoostStack BoostStacking = "stack"
+	// BoostMax оставляет ускорение с наибольшим множителем
+	BoostMax BoostStacking = "max"
+)
+
+// ActiveBoost - действующее временное ускорение производства ресурса Target.
+// Ускорения с одинаковым Key сочетаются по правилу Stacking
+type ActiveBoost struct {
+	Key       string        `json:"key"`
+	Target    string        `json:"target"`
+	Value     float64       `json:"value"`
+	Stacking  BoostStacking `json:"stacking"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+}
+
+// applyBoostEffect запускает временное ускорение из эффекта boost с момента now
+func (g *Game) applyBoostEffect(player *Player, effect Effect, now time.Time) {
+	value, err := effectValue(player, effect)
+	if err != nil {
+		log.Printf("Error evaluating boost expression: %v", err)
+		return
+	}
+	if effect.Duration <= 0 {
+		log.Printf("Boost on %s has no duration", effect.Target)
+		return
+	}
+
+	boost := ActiveBoost{
+		Key:       effect.ID,
+		Target:    effect.Target,
+		Value:     value,
+		Stacking:  BoostStacking(effect.Stacking),
+		ExpiresAt: now.Add(time.Duration(effect.Duration * float64(time.Second))),
+	}
+	if boost.Key == "" {
+		boost.Key = boost.Target
+	}
+	if boost.Stacking == "" {
+		boost.Stacking = BoostRefresh
+	}
+	player.addBoost(boost, now)
+	player.RecalculateState()
+
+	player.AddLog(fmt.Sprintf("Boost: x%v %s for %s", value, effect.Target, boost.ExpiresAt.Sub(now).Round(time.Second)))
+	g.EventSystem.Emit("BoostStarted", map[string]interface{}{
+		"PlayerID":  player.ID,
+		"Boost":     boost.Key,
+		"Target":    boost.Target,
+		"Value":     boost.Value,
+		"ExpiresAt": boost.ExpiresAt,
+	})
+}
+
+// boostStart возвращает момент начала ускорения. Производство начисляется игроку начиная
+// с LastSaveTime, поэтому ускорение отсчитывается от него: так оно учитывается ровно
+// Duration и при обычном тике, и на отрезках оффлайн-прогресса
+func (g *Game) boostStart(player *Player) time.Time {
+	if player.State.LastSaveTime.IsZero() {
+		return g.Clock.Now()
+	}
+	return player.State.LastSaveTime
+}
+
+// addBoost сочетает новое ускорение с активными по правилу его Stacking
+func (p *Player) addBoost(boost ActiveBoost, now time.Time) {
+	if boost.Stacking == BoostStack {
+		p.State.Boosts = append(p.State.Boosts, boost)
+		return
+	}
+
+	for i, active := range p.State.Boosts {
+		if active.Key != boost.Key || !active.ExpiresAt.After(now) {
+			continue
+		}
+		switch boost.Stacking {
+		case BoostExtend:
+			p.State.Boosts[i].ExpiresAt = active.ExpiresAt.Add(boost.ExpiresAt.Sub(now))
+		case BoostMax:
+			if boost.Value > active.Value {
+				p.State.Boosts[i] = boost
+			} else if boost.Value == active.Value && boost.ExpiresAt.After(active.ExpiresAt) {
+				p.State.Boosts[i].ExpiresAt = boost.ExpiresAt
+			}
+		default:
+			p.State.Boosts[i] = boost
+		}
+		return
+	}
+	p.State.Boosts = append(p.State.Boosts, boost)
+}
+
+// boostModifiers сворачивает активные ускорения в множители: бонусы экземпляров
+// с одним ключом складываются
+func (p *Player) boostModifiers() []Modifier {
+	bonuses := make(map[string]float64)
+	targets := make(map[string]string)
+	for _, boost := range p.State.Boosts {
+		bonuses[boost.Key] += boost.Value - 1
+		targets[boost.Key] = boost.Target
+	}
+
+	modifiers := make([]Modifier, 0, len(bonuses))
+	for key, bonus := range bonuses {
+		modifiers = append(modifiers, Modifier{
+			Source: "boost:" + key,
+			Target: targets[key],
+			Stage:  StageMultiplicative,
+			Value:  1 + bonus,
+		})
+	}
+	return modifiers
+}
+
+// nextBoostExpiry возвращает ближайший момент окончания ускорения
+func (p *Player) nextBoostExpiry() (time.Time, bool) {
+	var next time.Time
+	for _, boost := range p.State.Boosts {
+		if next.IsZero() || boost.ExpiresAt.Before(next) {
+			next = boost.ExpiresAt
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// expireBoosts убирает ускорения, закончившиеся к моменту now, и возвращает их
+func (p *Player) expireBoosts(now time.Time) []ActiveBoost {
+	expired := make([]ActiveBoost, 0)
+	active := p.State.Boosts[:0]
+	for _, boost := range p.State.Boosts {
+		if boost.ExpiresAt.After(now) {
+			active = append(active, boost)
+		} else {
+			expired = append(expired, boost)
+		}
+	}
+	p.State.Boosts = active
+	if len(expired) > 0 {
+		p.RecalculateState()
+	}
+	return expired
+}
+
+// produceWithBoosts начисляет производство от from до to, разбивая промежуток
+// в моменты окончания ускорений: каждое ускорение учитывается ровно за время своего действия
+func (g *Game) produceWithBoosts(player *Player, from, to time.Time) {
+	g.expireBoosts(player, from)
+	for {
+		next, ok := player.nextBoostExpiry()
+		if !ok || !next.Before(to) {
+			break
+		}
+		g.produce(player, next.Sub(from))
+		g.expireBoosts(player, next)
+		from = next
+	}
+	g.produce(player, to.Sub(from))
+}
+
+// expireBoosts снимает закончившиеся ускорения и сообщает об этом
+func (g *Game) expireBoosts(player *Player, now time.Time) {
+	for _, boost := range player.expireBoosts(now) {
+		g.EventSystem.Emit("BoostExpired", map[string]interface{}{
+			"PlayerID": player.ID,
+			"Boost":    boost.Key,
+			"Target":   boost.Target,
+		})
+	}
+}
+
+// GetActiveBoosts возвращает действующие ускорения игрока в порядке окончания
+func (g *Game) GetActiveBoosts(player *Player) []ActiveBoost {
+	now := g.Clock.Now()
+	boosts := make([]ActiveBoost, 0, len(player.State.Boosts))
+	for _, boost := range player.State.Boosts {
+		if boost.ExpiresAt.After(now) {
+			boosts = append(boosts, boost)
+		}
+	}
+	sort.SliceStable(boosts, func(i, j int) bool { return boosts[i].ExpiresAt.Before(boosts[j].ExpiresAt) })
+	return boosts
+}
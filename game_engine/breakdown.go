@@ -0,0 +1,98 @@
+package game_engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// RateBreakdown показывает, из чего складывается производство ресурса. Final - производство
+// после модификаторов, Flows - расход (отрицательный) и выпуск переработчиков при полной
+// загрузке, Net - итоговое изменение ресурса в секунду
+type RateBreakdown struct {
+	Resource  string            `json:"resource"`
+	Sources   []RateSource      `json:"sources"`
+	Base      bignum.Number     `json:"base"`
+	Modifiers []AppliedModifier `json:"modifiers"`
+	Final     bignum.Number     `json:"final"`
+	Flows     []RateSource      `json:"flows"`
+	Net       bignum.Number     `json:"net"`
+}
+
+// RateSource - вклад одного предмета в базовое производство ресурса
+type RateSource struct {
+	ItemID string        `json:"itemId"`
+	Name   string        `json:"name"`
+	Owned  bignum.Number `json:"owned"`
+	Yield  bignum.Number `json:"yield"`
+}
+
+// AppliedModifier - модификатор и значение производства до и после его применения
+type AppliedModifier struct {
+	Modifier
+	Before bignum.Number `json:"before"`
+	After  bignum.Number `json:"after"`
+}
+
+func breakdownFor(breakdowns map[string]*RateBreakdown, resource string) *RateBreakdown {
+	breakdown, ok := breakdowns[resource]
+	if !ok {
+		breakdown = &RateBreakdown{
+			Resource:  resource,
+			Sources:   make([]RateSource, 0),
+			Modifiers: make([]AppliedModifier, 0),
+			Flows:     make([]RateSource, 0),
+		}
+		breakdowns[resource] = breakdown
+	}
+	return breakdown
+}
+
+func (b *RateBreakdown) addSource(item *PlayerItem, yield bignum.Number) {
+	b.Sources = append(b.Sources, RateSource{
+		ItemID: item.ID,
+		Name:   item.Name,
+		Owned:  item.Amount,
+		Yield:  yield,
+	})
+	b.Base = b.Base.Add(yield)
+}
+
+func (b *RateBreakdown) addFlow(item *PlayerItem, rate bignum.Number) {
+	b.Flows = append(b.Flows, RateSource{
+		ItemID: item.ID,
+		Name:   item.Name,
+		Owned:  item.Amount,
+		Yield:  rate,
+	})
+	b.Net = b.Net.Add(rate)
+}
+
+func (b *RateBreakdown) sortSources() {
+	sort.Slice(b.Sources, func(i, j int) bool { return b.Sources[i].ItemID < b.Sources[j].ItemID })
+}
+
+// GetProductionBreakdown возвращает разбивку производства по всем ресурсам игрока
+func (g *Game) GetProductionBreakdown(player *Player) map[string]*RateBreakdown {
+	if player.Breakdown == nil {
+		player.RecalculateState()
+	}
+	return player.Breakdown
+}
+
+// GetRateBreakdown возвращает разбивку производства одного ресурса
+func (g *Game) GetRateBreakdown(player *Player, resource string) (*RateBreakdown, error) {
+	if player.GetItem(resource) == nil {
+		return nil, fmt.Errorf("resource not found: %s", resource)
+	}
+	if breakdown, ok := g.GetProductionBreakdown(player)[resource]; ok {
+		return breakdown, nil
+	}
+	return &RateBreakdown{
+		Resource:  resource,
+		Sources:   make([]RateSource, 0),
+		Modifiers: make([]AppliedModifier, 0),
+		Flows:     make([]RateSource, 0),
+	}, nil
+}
@@ -0,0 +1,384 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// PurchaseMode определяет, сколько единиц предмета покупается за раз
+type PurchaseMode string
+
+const (
+	// PurchaseCount - покупка заданного количества единиц
+	PurchaseCount PurchaseMode = "count"
+	// PurchaseMax - покупка максимального количества, которое может позволить себе игрок
+	PurchaseMax PurchaseMode = "max"
+	// PurchaseNextMilestone - покупка до следующего порога количества
+	PurchaseNextMilestone PurchaseMode = "next"
+)
+
+const (
+	// maxBulkPurchase ограничивает количество единиц в одной покупке
+	maxBulkPurchase = 1_000_000_000
+	// maxIterativePurchase ограничивает покупку для моделей стоимости без формулы суммы
+	maxIterativePurchase = 10_000
+)
+
+// purchasableCategories - категории, предметы которых покупаются за ресурсы. Престиж, исследования,
+// испытания, события, находки и достижения получаются своими путями, ресурсы - производством
+var purchasableCategories = map[string]bool{
+	"buildings":     true,
+	"upgrades":      true,
+	"meta_upgrades": true,
+	"automators":    true,
+}
+
+// defaultMilestones - пороги количества, до которых округляет покупка PurchaseNextMilestone
+// у предметов без собственных вех по количеству. После последнего порога используются кратные 100
+var defaultMilestones = []int{10, 25, 50, 100}
+
+// PurchaseRequest описывает желаемую покупку
+type PurchaseRequest struct {
+	Mode  PurchaseMode `json:"mode"`
+	Count int          `json:"count"`
+}
+
+// PurchaseQuote - результат расчета покупки: сколько единиц, за какую цену и сколько будет у игрока
+type PurchaseQuote struct {
+	ItemID     string                   `json:"itemId"`
+	Count      int                      `json:"count"`
+	Owned      int                      `json:"owned"`
+	Resulting  int                      `json:"resulting"`
+	Cost       map[string]bignum.Number `json:"cost"`
+	Affordable bool                     `json:"affordable"`
+}
+
+// QuoteBuy рассчитывает покупку, не изменяя состояние игрока
+func (g *Game) QuoteBuy(player *Player, itemID string, req PurchaseRequest) (PurchaseQuote, error) {
+	return g.quoteBuy(player, NewExpressionEvaluator(player), itemID, req)
+}
+
+// quoteBuy рассчитывает покупку на снимке параметров evaluator. Вызывающий, который
+// сравнивает много предметов, переиспользует один вычислитель, пока состояние не изменилось
+func (g *Game) quoteBuy(player *Player, evaluator *ExpressionEvaluator, itemID string, req PurchaseRequest) (PurchaseQuote, error) {
+	item := player.GetItem(itemID)
+	content, ok := g.ContentSystem.GetItem(itemID)
+	if item == nil || !ok {
+		return PurchaseQuote{}, fmt.Errorf("item not found: %s", itemID)
+	}
+	if !purchasableCategories[content.Type] {
+		return PurchaseQuote{}, fmt.Errorf("%s cannot be bought: %s", content.Type, itemID)
+	}
+	// Пустая стоимость прошла бы проверку CanAfford и выдала бы предмет бесплатно
+	if len(content.Cost) == 0 {
+		return PurchaseQuote{}, fmt.Errorf("item has no cost and cannot be bought: %s", itemID)
+	}
+	if !g.ContentSystem.IsAvailable(itemID) {
+		return PurchaseQuote{}, fmt.Errorf("item is not available now: %s", itemID)
+	}
+	if availability := g.itemAvailability(player, evaluator, content); len(availability.Unmet) > 0 {
+		return PurchaseQuote{}, &RequirementsError{ItemID: itemID, Unmet: availability.Unmet}
+	}
+	if challenge, ok := player.activeChallenge(); ok && challenge.Restrictions.isDisabled(item.ID, item.Type) {
+		return PurchaseQuote{}, fmt.Errorf("item %s is disabled by challenge %s", itemID, challenge.ID)
+	}
+
+	owned := player.GetItemCount(itemID)
+	var count int
+	switch req.Mode {
+	case PurchaseMax:
+		count = g.maxAffordable(player, evaluator, itemID, owned)
+	case PurchaseNextMilestone:
+		count = g.nextMilestone(itemID, owned) - owned
+	case PurchaseCount, "":
+		count = req.Count
+		if count <= 0 {
+			return PurchaseQuote{}, fmt.Errorf("purchase quantity must be positive: %d", count)
+		}
+	default:
+		return PurchaseQuote{}, fmt.Errorf("unknown purchase mode: %s", req.Mode)
+	}
+
+	cost, err := g.totalCost(player, evaluator, itemID, owned, count)
+	if err != nil {
+		return PurchaseQuote{}, err
+	}
+
+	return PurchaseQuote{
+		ItemID:     itemID,
+		Count:      count,
+		Owned:      owned,
+		Resulting:  owned + count,
+		Cost:       cost,
+		Affordable: count > 0 && player.CanAfford(cost),
+	}, nil
+}
+
+// BuyBulk покупает несколько единиц предмета одной операцией
+func (g *Game) BuyBulk(player *Player, itemID string, req PurchaseRequest) (PurchaseQuote, error) {
+	quote, err := g.QuoteBuy(player, itemID, req)
+	if err != nil {
+		return quote, err
+	}
+	if !quote.Affordable {
+		return quote, fmt.Errorf("cannot afford item: %s", itemID)
+	}
+
+	item := player.GetItem(itemID)
+	player.SpendResources(quote.Cost)
+	item.Amount = item.Amount.Add(bignum.FromInt(quote.Count))
+	player.recordBought(itemID, quote.Count)
+	log.Printf("Player %s bought item: %s x%d (now have %d)", player.ID, item.Name, quote.Count, quote.Resulting)
+	player.AddLog(fmt.Sprintf("Bought item: %s x%d (now have %d)", item.Name, quote.Count, quote.Resulting))
+
+	player.RecalculateState()
+	g.checkMilestones(player)
+
+	g.EventSystem.Emit("BuildingBought", map[string]interface{}{
+		"PlayerID": player.ID,
+		"ItemID":   item.ID,
+		"Amount":   quote.Resulting,
+		"Count":    quote.Count,
+	})
+	return quote, nil
+}
+
+// totalCost возвращает суммарную стоимость count единиц предмета начиная с owned
+func (g *Game) totalCost(player *Player, evaluator *ExpressionEvaluator, itemID string, owned, count int) (map[string]bignum.Number, error) {
+	baseCost, curve := g.costModel(player, itemID)
+	return curveTotalCost(evaluator, itemID, baseCost, curve, owned, count)
+}
+
+// curveTotalCost возвращает суммарную стоимость count единиц начиная с owned по базовой
+// стоимости baseCost и модели роста curve
+func curveTotalCost(evaluator *ExpressionEvaluator, itemID string, baseCost map[string]float64, curve CostCurve, owned, count int) (map[string]bignum.Number, error) {
+	total := make(map[string]bignum.Number, len(baseCost))
+	if count <= 0 {
+		return total, nil
+	}
+
+	if multiplier, ok := curve.TotalMultiplier(owned, count); ok {
+		for resource, amount := range baseCost {
+			total[resource] = bignum.FromFloat(amount).Mul(multiplier)
+		}
+		return total, nil
+	}
+
+	if count > maxIterativePurchase {
+		return nil, fmt.Errorf("cannot price more than %d units of %s at once", maxIterativePurchase, itemID)
+	}
+	for i := 0; i < count; i++ {
+		for resource, amount := range curveCost(evaluator, itemID, baseCost, curve, owned+i) {
+			total[resource] = total[resource].Add(amount)
+		}
+	}
+	return total, nil
+}
+
+// maxAffordable возвращает максимальное количество единиц, которое игрок может купить сейчас
+func (g *Game) maxAffordable(player *Player, evaluator *ExpressionEvaluator, itemID string, owned int) int {
+	baseCost, curve := g.costModel(player, itemID)
+	if len(baseCost) == 0 {
+		return 0
+	}
+
+	if curve.Type == CostExpression {
+		budget := make(map[string]bignum.Number, len(baseCost))
+		for resource := range baseCost {
+			budget[resource] = player.GetItemAmount(resource)
+		}
+
+		count := 0
+		for ; count < maxIterativePurchase; count++ {
+			cost := g.calculateCost(player, evaluator, itemID, owned+count)
+			for resource, amount := range cost {
+				if amount.IsZero() || budget[resource].Lt(amount) {
+					return count
+				}
+				budget[resource] = budget[resource].Sub(amount)
+			}
+		}
+		return count
+	}
+
+	// Для остальных моделей стоимость каждого ресурса равна base * множитель,
+	// поэтому достаточно найти самый "узкий" ресурс
+	var ratio bignum.Number
+	first := true
+	for resource, amount := range baseCost {
+		if amount <= 0 {
+			return 0
+		}
+		r := player.GetItemAmount(resource).Div(bignum.FromFloat(amount))
+		if first || r.Lt(ratio) {
+			ratio = r
+			first = false
+		}
+	}
+	if ratio.Sign() <= 0 {
+		return 0
+	}
+
+	if count, ok := curve.MaxAffordable(owned, ratio); ok {
+		return count
+	}
+
+	spent := bignum.Zero()
+	count := 0
+	for ; count < maxIterativePurchase; count++ {
+		spent = spent.Add(curve.Multiplier(owned + count))
+		if spent.Gt(ratio) {
+			break
+		}
+	}
+	return count
+}
+
+// nextMilestone возвращает ближайший порог количества, превышающий owned: сначала
+// среди вех предмета, затем среди порогов по умолчанию
+func (g *Game) nextMilestone(itemID string, owned int) int {
+	if item, ok := g.ContentSystem.GetItem(itemID); ok {
+		for _, milestone := range item.Milestones {
+			if milestone.Count > owned {
+				return milestone.Count
+			}
+		}
+	}
+	for _, milestone := range defaultMilestones {
+		if owned < milestone {
+			return milestone
+		}
+	}
+	return (owned/100 + 1) * 100
+}
+
+// TotalMultiplier возвращает сумму множителей для покупки count единиц начиная с owned,
+// если для модели есть формула суммы
+func (c CostCurve) TotalMultiplier(owned, count int) (bignum.Number, bool) {
+	if count <= 0 {
+		return bignum.Zero(), true
+	}
+
+	switch c.Type {
+	case CostLinear, "":
+		// (owned+1) + ... + (owned+count) = count * (2*owned + count + 1) / 2
+		return bignum.FromInt(count).Mul(bignum.FromInt(2*owned + count + 1)).MulFloat(0.5), true
+	case CostExponential:
+		if c.Rate == 1 {
+			return bignum.FromInt(count), true
+		}
+		// rate^owned * (rate^count - 1) / (rate - 1)
+		rate := bignum.FromFloat(c.Rate)
+		return rate.Pow(float64(owned)).Mul(rate.Pow(float64(count)).Sub(bignum.One())).Div(bignum.FromFloat(c.Rate - 1)), true
+	case CostStep:
+		total := bignum.Zero()
+		c.forEachStepSegment(owned, func(from, to int, multiplier float64) bool {
+			end := owned + count
+			if to == -1 || to > end {
+				to = end
+			}
+			total = total.Add(bignum.FromFloat(multiplier).Mul(bignum.FromInt(to - from)))
+			return to < end
+		})
+		return total, true
+	default:
+		return bignum.Zero(), false
+	}
+}
+
+// MaxAffordable возвращает максимальное количество единиц, сумма множителей которых не превышает ratio,
+// если для модели есть обратная формула
+func (c CostCurve) MaxAffordable(owned int, ratio bignum.Number) (int, bool) {
+	var estimate float64
+	switch c.Type {
+	case CostLinear, "":
+		// count^2 + (2*owned+1)*count - 2*ratio <= 0
+		b := float64(2*owned + 1)
+		estimate = (-b + math.Sqrt(b*b+8*ratio.Float64())) / 2
+	case CostExponential:
+		switch {
+		case c.Rate == 1:
+			estimate = ratio.Float64()
+		case c.Rate > 1:
+			// count = log_rate(1 + ratio * (rate - 1) / rate^owned)
+			rate := bignum.FromFloat(c.Rate)
+			x := ratio.MulFloat(c.Rate - 1).Div(rate.Pow(float64(owned))).Add(bignum.One())
+			estimate = x.Log10() / math.Log10(c.Rate)
+		default:
+			return 0, false
+		}
+	case CostStep:
+		count := 0
+		remaining := ratio
+		c.forEachStepSegment(owned, func(from, to int, multiplier float64) bool {
+			if multiplier <= 0 {
+				count = maxBulkPurchase
+				return false
+			}
+			affordable := remaining.Div(bignum.FromFloat(multiplier)).Floor()
+			if to == -1 || affordable.Lt(bignum.FromInt(to-from)) {
+				count += affordable.Int()
+				return false
+			}
+			count += to - from
+			remaining = remaining.Sub(bignum.FromFloat(multiplier).Mul(bignum.FromInt(to - from)))
+			return true
+		})
+		return clampPurchase(count), true
+	default:
+		return 0, false
+	}
+
+	if math.IsNaN(estimate) || estimate < 0 {
+		estimate = 0
+	}
+	count := clampPurchase(int(math.Min(estimate, maxBulkPurchase)))
+
+	// Поправка на погрешность вычислений с плавающей точкой
+	for count > 0 {
+		if total, _ := c.TotalMultiplier(owned, count); total.Lte(ratio) {
+			break
+		}
+		count--
+	}
+	for count < maxBulkPurchase {
+		if total, _ := c.TotalMultiplier(owned, count+1); total.Gt(ratio) {
+			break
+		}
+		count++
+	}
+	return count, true
+}
+
+// forEachStepSegment обходит участки постоянной цены ступенчатой модели, начиная с owned.
+// Для последнего участка to равен -1. Обход прекращается, если fn вернула false
+func (c CostCurve) forEachStepSegment(owned int, fn func(from, to int, multiplier float64) bool) {
+	from := owned
+	for {
+		multiplier := c.Multiplier(from).Float64()
+		to := -1
+		for _, step := range c.Steps {
+			if step.Owned > from {
+				to = step.Owned
+				break
+			}
+		}
+		if !fn(from, to, multiplier) || to == -1 {
+			return
+		}
+		from = to
+	}
+}
+
+func clampPurchase(count int) int {
+	if count < 0 {
+		return 0
+	}
+	if count > maxBulkPurchase {
+		return maxBulkPurchase
+	}
+	return count
+}
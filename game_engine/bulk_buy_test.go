@@ -0,0 +1,168 @@
+package game_engine
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+	"github.com/ralist/game_engine/game_engine/config"
+)
+
+var testCurves = map[string]CostCurve{
+	"linear":      {Type: CostLinear},
+	"default":     {},
+	"exponential": {Type: CostExponential, Rate: 1.15},
+	"flat":        {Type: CostExponential, Rate: 1},
+	"step": {Type: CostStep, Steps: []CostTier{
+		{Owned: 3, Multiplier: 2},
+		{Owned: 7, Multiplier: 5},
+		{Owned: 12, Multiplier: 20},
+	}},
+}
+
+// bruteTotal суммирует множители по одной единице
+func bruteTotal(c CostCurve, owned, count int) bignum.Number {
+	total := bignum.Zero()
+	for i := 0; i < count; i++ {
+		total = total.Add(c.Multiplier(owned + i))
+	}
+	return total
+}
+
+// bruteMax покупает по одной единице, пока хватает ratio
+func bruteMax(c CostCurve, owned int, ratio bignum.Number) int {
+	spent := bignum.Zero()
+	count := 0
+	for {
+		spent = spent.Add(c.Multiplier(owned + count))
+		if spent.Gt(ratio) {
+			return count
+		}
+		count++
+	}
+}
+
+func closeTo(a, b bignum.Number) bool {
+	x, y := a.Float64(), b.Float64()
+	return math.Abs(x-y) <= 1e-9*math.Max(1, math.Abs(y))
+}
+
+func TestTotalMultiplierMatchesSum(t *testing.T) {
+	for name, curve := range testCurves {
+		for owned := 0; owned <= 15; owned++ {
+			for count := 0; count <= 15; count++ {
+				got, ok := curve.TotalMultiplier(owned, count)
+				if !ok {
+					t.Fatalf("%s: no closed form", name)
+				}
+				if want := bruteTotal(curve, owned, count); !closeTo(got, want) {
+					t.Errorf("%s: TotalMultiplier(%d, %d) = %v, want %v", name, owned, count, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestMaxAffordableMatchesBruteForce(t *testing.T) {
+	for name, curve := range testCurves {
+		for owned := 0; owned <= 15; owned++ {
+			for count := 1; count <= 15; count++ {
+				exact := bruteTotal(curve, owned, count)
+				// Бюджеты чуть выше и чуть ниже точной суммы: на самой границе поштучная сумма
+				// и формула расходятся в последнем бите
+				for _, ratio := range []bignum.Number{exact.MulFloat(1 + 1e-9), exact.MulFloat(1 - 1e-9)} {
+					got, ok := curve.MaxAffordable(owned, ratio)
+					if !ok {
+						t.Fatalf("%s: no inverse formula", name)
+					}
+					if want := bruteMax(curve, owned, ratio); got != want {
+						t.Errorf("%s: MaxAffordable(%d, %v) = %d, want %d", name, owned, ratio, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMaxAffordableAtExactBudget(t *testing.T) {
+	// Ровно на границе покупка должна сходиться с ценой, которую спишет totalCost
+	for name, curve := range testCurves {
+		for owned := 0; owned <= 15; owned++ {
+			for count := 1; count <= 15; count++ {
+				ratio, _ := curve.TotalMultiplier(owned, count)
+				got, _ := curve.MaxAffordable(owned, ratio)
+				if got != count {
+					t.Errorf("%s: MaxAffordable(%d, %v) = %d, want %d", name, owned, ratio, got, count)
+				}
+			}
+		}
+	}
+}
+
+func TestClosedFormsUnavailable(t *testing.T) {
+	for _, curve := range []CostCurve{
+		{Type: CostPolynomial, Power: 2},
+		{Type: CostExpression, Expression: "base * owned"},
+	} {
+		if _, ok := curve.TotalMultiplier(1, 2); ok {
+			t.Errorf("%s: unexpected sum formula", curve.Type)
+		}
+		if _, ok := curve.MaxAffordable(1, bignum.FromInt(10)); ok {
+			t.Errorf("%s: unexpected inverse formula", curve.Type)
+		}
+	}
+	if _, ok := (CostCurve{Type: CostExponential, Rate: 0.5}).MaxAffordable(0, bignum.FromInt(10)); ok {
+		t.Error("decreasing exponential curve must fall back to iteration")
+	}
+}
+
+func TestBuyRejectsNonPurchasableItems(t *testing.T) {
+	game, player := newTestGame(t)
+	gold := player.GetItemAmount("gold")
+	for _, id := range []string{"gold", "gold_coin_shiny", "hand_panning", "gold_rush_festival", "novice_prospector", "prestige"} {
+		if err := game.Buy(player, id); err == nil {
+			t.Errorf("%s must not be purchasable", id)
+		}
+	}
+	if _, err := game.BuyBulk(player, "gold", PurchaseRequest{Mode: PurchaseCount, Count: 1000000}); err == nil {
+		t.Error("resources must not be purchasable in bulk")
+	}
+	if got := player.GetItemAmount("gold"); !got.Eq(gold) {
+		t.Errorf("gold changed from %v to %v", gold, got)
+	}
+}
+
+func TestBuyRejectsCostlessItems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	source := `content:
+  resources:
+    gold:
+      name: Gold
+      initial: 100
+  buildings:
+    shed:
+      name: Free Shed
+`
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := NewGame(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	player := NewPlayer("player", game.ContentSystem)
+
+	if _, err := game.BuyBulk(player, "shed", PurchaseRequest{Mode: PurchaseCount, Count: 5}); err == nil || !strings.Contains(err.Error(), "no cost") {
+		t.Errorf("an item without a cost must not be purchasable, got %v", err)
+	}
+	if owned := player.GetItemCount("shed"); owned != 0 {
+		t.Errorf("shed owned = %d, want 0", owned)
+	}
+}
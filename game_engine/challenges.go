@@ -0,0 +1,281 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultChallengeResets - что сбрасывается при входе в испытание, если resets не задан
+var defaultChallengeResets = []string{"resources", "buildings", "upgrades"}
+
+// Challenge - испытание из категории challenges: забег с ограничениями, который
+// завершается, когда выражение Goal становится истинным. Effects выдаются как постоянные
+// модификаторы при первом прохождении
+type Challenge struct {
+	ID           string
+	Name         string
+	Goal         string
+	Reqs         []string
+	Resets       []string
+	Keeps        []string
+	Restrictions ChallengeRestrictions
+	Effects      []Effect
+}
+
+// ChallengeRestrictions - ограничения, действующие во время испытания.
+// Ключи Cost - ID предмета, категория или "all"; ключи Production - ресурсы
+type ChallengeRestrictions struct {
+	Disabled   []string
+	Cost       map[string]float64
+	Production map[string]float64
+}
+
+// ChallengeRecord - результаты игрока в испытании
+type ChallengeRecord struct {
+	Completions   int           `json:"completions"`
+	BestTime      time.Duration `json:"bestTime"`
+	LastCompleted time.Time     `json:"lastCompleted"`
+}
+
+// ChallengeStatus описывает испытание для интерфейса
+type ChallengeStatus struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Goal        string        `json:"goal"`
+	Active      bool          `json:"active"`
+	Elapsed     time.Duration `json:"elapsed,omitempty"`
+	Completions int           `json:"completions"`
+	BestTime    time.Duration `json:"bestTime,omitempty"`
+}
+
+// parseChallenges создает испытания из категории challenges
+func parseChallenges(items map[string]GameItem) (map[string]Challenge, error) {
+	challenges := make(map[string]Challenge, len(items))
+	for id, item := range items {
+		challenge := Challenge{
+			ID:      id,
+			Name:    item.Name,
+			Reqs:    item.Reqs,
+			Effects: item.Effects,
+			Resets:  toStringSlice(item.Properties["resets"]),
+			Keeps:   toStringSlice(item.Properties["keeps"]),
+		}
+		if goal, ok := item.Properties["goal"].(string); ok {
+			challenge.Goal = goal
+		}
+		if challenge.Goal == "" {
+			return nil, fmt.Errorf("challenge %s has no goal", id)
+		}
+		if len(challenge.Resets) == 0 {
+			challenge.Resets = defaultChallengeResets
+		}
+
+		if restrictions, ok := item.Properties["restrictions"].(map[string]interface{}); ok {
+			challenge.Restrictions.Disabled = toStringSlice(restrictions["disabled"])
+			challenge.Restrictions.Cost = toFloatMap(restrictions["cost"])
+			challenge.Restrictions.Production = toFloatMap(restrictions["production"])
+		}
+		challenges[id] = challenge
+	}
+	return challenges, nil
+}
+
+// GetChallenge возвращает испытание по ID
+func (cs *ContentSystem) GetChallenge(id string) (Challenge, error) {
+	challenge, ok := cs.challenges[id]
+	if !ok {
+		return Challenge{}, fmt.Errorf("challenge not found: %s", id)
+	}
+	return challenge, nil
+}
+
+// activeChallenge возвращает испытание, в котором сейчас находится игрок
+func (p *Player) activeChallenge() (Challenge, bool) {
+	if p.State.ActiveChallenge == "" || p.Config == nil {
+		return Challenge{}, false
+	}
+	challenge, err := p.Config.GetChallenge(p.State.ActiveChallenge)
+	return challenge, err == nil
+}
+
+// isDisabled проверяет, запрещена ли покупка предмета ограничениями испытания
+func (r ChallengeRestrictions) isDisabled(itemID, itemType string) bool {
+	for _, disabled := range r.Disabled {
+		if disabled == itemID || disabled == itemType {
+			return true
+		}
+	}
+	return false
+}
+
+// costMultiplier возвращает штраф к стоимости предмета
+func (r ChallengeRestrictions) costMultiplier(itemID, itemType string) float64 {
+	multiplier := 1.0
+	for _, target := range []string{itemID, itemType, "all"} {
+		if value, ok := r.Cost[target]; ok {
+			multiplier *= value
+		}
+	}
+	return multiplier
+}
+
+// challengeModifiers возвращает ослабления производства активного испытания
+func (p *Player) challengeModifiers() []Modifier {
+	challenge, ok := p.activeChallenge()
+	if !ok {
+		return nil
+	}
+	modifiers := make([]Modifier, 0, len(challenge.Restrictions.Production))
+	for resource, value := range challenge.Restrictions.Production {
+		modifiers = append(modifiers, Modifier{
+			Source: challenge.ID,
+			Target: resource,
+			Stage:  StageMultiplicative,
+			Value:  value,
+		})
+	}
+	return modifiers
+}
+
+// StartChallenge сбрасывает прогресс игрока и начинает испытание
+func (g *Game) StartChallenge(player *Player, challengeID string) error {
+	challenge, err := g.ContentSystem.GetChallenge(challengeID)
+	if err != nil {
+		return err
+	}
+	if player.State.ActiveChallenge != "" {
+		return fmt.Errorf("already in challenge: %s", player.State.ActiveChallenge)
+	}
+	if !g.requirementsMet(NewExpressionEvaluator(player), challenge.Reqs) {
+		return fmt.Errorf("requirements not met for challenge: %s", challengeID)
+	}
+
+	scope := ResetScope{
+		Resets: challenge.Resets,
+		Keeps:  append([]string(nil), challenge.Keeps...),
+	}
+	// Валюты престижа испытание не отнимает
+	for _, layer := range g.ContentSystem.GetPrestigeLayers() {
+		if layer.Currency != "" {
+			scope.Keeps = append(scope.Keeps, layer.Currency)
+		}
+	}
+
+	player.State.ActiveChallenge = challenge.ID
+	player.State.ChallengeStarted = g.Clock.Now()
+	player.ResetProgress(scope)
+	// Испытание - отдельный забег: статистика забега начинается заново, как после престижа
+	player.State.Stats.Run = newStatistics()
+
+	log.Printf("Player %s started challenge %s", player.ID, challenge.ID)
+	player.AddLog(fmt.Sprintf("Challenge started: %s", challenge.Name))
+	g.EventSystem.Emit("ChallengeStarted", map[string]interface{}{
+		"PlayerID":    player.ID,
+		"ChallengeID": challenge.ID,
+	})
+	return nil
+}
+
+// AbandonChallenge снимает ограничения испытания без его прохождения
+func (g *Game) AbandonChallenge(player *Player) error {
+	challenge, ok := player.activeChallenge()
+	if !ok {
+		return fmt.Errorf("not in a challenge")
+	}
+
+	player.State.ActiveChallenge = ""
+	player.State.ChallengeStarted = time.Time{}
+	player.RecalculateState()
+
+	player.AddLog(fmt.Sprintf("Challenge abandoned: %s", challenge.Name))
+	g.EventSystem.Emit("ChallengeAbandoned", map[string]interface{}{
+		"PlayerID":    player.ID,
+		"ChallengeID": challenge.ID,
+	})
+	return nil
+}
+
+// challengeTick - этап тика, завершающий активное испытание при достижении цели
+func (g *Game) challengeTick(player *Player, now time.Time, dt time.Duration) {
+	challenge, ok := player.activeChallenge()
+	if !ok {
+		return
+	}
+	met, err := NewExpressionEvaluator(player).Evaluate(challenge.Goal)
+	if err != nil {
+		log.Printf("Error evaluating goal of challenge %s: %v", challenge.ID, err)
+		return
+	}
+	if met > 0 {
+		g.completeChallenge(player, challenge, now)
+	}
+}
+
+// completeChallenge записывает результат, выдает награду за первое прохождение и снимает ограничения
+func (g *Game) completeChallenge(player *Player, challenge Challenge, now time.Time) {
+	elapsed := now.Sub(player.State.ChallengeStarted)
+	record := player.State.Challenges[challenge.ID]
+	record.Completions++
+	record.LastCompleted = now
+	if record.BestTime == 0 || elapsed < record.BestTime {
+		record.BestTime = elapsed
+	}
+	player.State.Challenges[challenge.ID] = record
+
+	if record.Completions == 1 {
+		g.grantPermanentEffects(player, challenge.ID, challenge.Effects)
+	}
+	player.State.ActiveChallenge = ""
+	player.State.ChallengeStarted = time.Time{}
+	player.RecalculateState()
+
+	log.Printf("Player %s completed challenge %s in %s", player.ID, challenge.ID, elapsed)
+	player.AddLog(fmt.Sprintf("Challenge completed: %s in %s", challenge.Name, elapsed.Round(time.Second)))
+	g.EventSystem.Emit("ChallengeCompleted", map[string]interface{}{
+		"PlayerID":    player.ID,
+		"ChallengeID": challenge.ID,
+		"Time":        elapsed,
+		"BestTime":    record.BestTime,
+		"Completions": record.Completions,
+	})
+}
+
+// GetChallenges возвращает состояние всех испытаний игрока
+func (g *Game) GetChallenges(player *Player) []ChallengeStatus {
+	ids := g.ContentSystem.GetSortedIDs("challenges")
+	statuses := make([]ChallengeStatus, 0, len(ids))
+	for _, id := range ids {
+		challenge, err := g.ContentSystem.GetChallenge(id)
+		if err != nil {
+			continue
+		}
+		record := player.State.Challenges[id]
+		status := ChallengeStatus{
+			ID:          id,
+			Name:        challenge.Name,
+			Goal:        challenge.Goal,
+			Active:      player.State.ActiveChallenge == id,
+			Completions: record.Completions,
+			BestTime:    record.BestTime,
+		}
+		if status.Active {
+			status.Elapsed = g.Clock.Now().Sub(player.State.ChallengeStarted)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// toFloatMap преобразует отображение чисел из YAML
+func toFloatMap(value interface{}) map[string]float64 {
+	raw, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]float64, len(raw))
+	for key, entry := range raw {
+		result[key] = toFloat(entry)
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package game_engine
+
+import (
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+func TestStartChallengeResetsRunStatistics(t *testing.T) {
+	game, player := newTestGame(t)
+	player.earn("gold", bignum.FromInt(500))
+
+	if err := game.StartChallenge(player, "hand_panning"); err != nil {
+		t.Fatal(err)
+	}
+	if earned := player.State.Stats.Run.Earned["gold"]; !earned.IsZero() {
+		t.Errorf("run earned gold = %v, want 0 after starting a challenge", earned)
+	}
+	if earned := player.State.Stats.AllTime.Earned["gold"]; !earned.Eq(bignum.FromInt(500)) {
+		t.Errorf("all-time earned gold = %v, want 500", earned)
+	}
+}
+
+func TestPrestigeBlockedDuringChallenge(t *testing.T) {
+	game, player := newTestGame(t)
+	player.earn("gold", bignum.FromFloat(1e8))
+	if quote, _ := game.QuotePrestige(player, "prestige"); !quote.Available {
+		t.Fatalf("prestige should be available before the challenge: %s", quote.Reason)
+	}
+
+	if err := game.StartChallenge(player, "inflation"); err != nil {
+		t.Fatal(err)
+	}
+	player.earn("gold", bignum.FromFloat(1e8))
+	if quote, _ := game.QuotePrestige(player, "prestige"); quote.Available {
+		t.Error("prestige must not be available during a challenge")
+	}
+	if err := game.PerformPrestige(player, "prestige"); err == nil {
+		t.Error("expected PerformPrestige to fail during a challenge")
+	}
+
+	if err := game.AbandonChallenge(player); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.PerformPrestige(player, "prestige"); err != nil {
+		t.Errorf("prestige after abandoning the challenge: %v", err)
+	}
+}
+
+func TestChallengeEffectsApplyOnlyOnCompletion(t *testing.T) {
+	game, player := newTestGame(t)
+	if err := game.Buy(player, "hand_panning"); err == nil {
+		t.Error("challenges must not be purchasable")
+	}
+
+	// Даже если предмет испытания оказался у игрока, его эффекты - награда за прохождение
+	player.State.Items["hand_panning"].Amount = bignum.FromInt(10)
+	player.RecalculateState()
+	for _, modifier := range player.collectModifiers() {
+		if modifier.Source == "hand_panning" {
+			t.Errorf("challenge effect applied as an item modifier: %+v", modifier)
+		}
+	}
+}
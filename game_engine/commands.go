@@ -2,7 +2,13 @@ package game_engine
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+	"github.com/ralist/game_engine/game_engine/formatter"
 )
 
 // Command представляет интерфейс для всех команд в игре
@@ -38,7 +44,27 @@ func (f *GameCommandFactory) CreateCommand(commandName string) Command {
 	case "listresources":
 		return &ListResourcesCommand{}
 	case "listbuildings":
-		return &ListBuildingsCommand{}
+		return &ListBuildingsCommand{game: f.game}
+	case "format":
+		return &FormatCommand{}
+	case "claim":
+		return &ClaimCommand{game: f.game}
+	case "breakdown":
+		return &BreakdownCommand{game: f.game}
+	case "challenge":
+		return &ChallengeCommand{game: f.game}
+	case "auto":
+		return &AutoCommand{game: f.game}
+	case "research":
+		return &ResearchCommand{game: f.game}
+	case "storage":
+		return &StorageCommand{game: f.game}
+	case "stats":
+		return &StatsCommand{game: f.game}
+	case "milestones":
+		return &MilestonesCommand{game: f.game}
+	case "available":
+		return &AvailableCommand{game: f.game}
 	default:
 		return nil
 	}
@@ -94,9 +120,12 @@ func (c *BuyCommand) Execute(player *Player, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("please specify what to buy")
 	}
-	itemName := strings.Join(args, " ")
-	c.game.Buy(player, itemName)
-	return nil
+	itemName, req, err := parsePurchaseArgs(args)
+	if err != nil {
+		return err
+	}
+	_, err = c.game.BuyBulk(player, itemName, req)
+	return err
 }
 
 func (c *BuyCommand) Name() string {
@@ -104,7 +133,33 @@ func (c *BuyCommand) Name() string {
 }
 
 func (c *BuyCommand) Description() string {
-	return "Buy a building or upgrade"
+	return "Buy a building or upgrade: buy <item> [count|max|next]"
+}
+
+// parsePurchaseArgs разбирает аргументы покупки: название предмета и необязательное количество
+func parsePurchaseArgs(args []string) (string, PurchaseRequest, error) {
+	req := PurchaseRequest{Mode: PurchaseCount, Count: 1}
+	if len(args) < 2 {
+		return strings.Join(args, " "), req, nil
+	}
+
+	last := strings.ToLower(args[len(args)-1])
+	switch last {
+	case string(PurchaseMax):
+		req.Mode = PurchaseMax
+	case string(PurchaseNextMilestone):
+		req.Mode = PurchaseNextMilestone
+	default:
+		count, err := strconv.Atoi(strings.TrimPrefix(last, "x"))
+		if err != nil {
+			return strings.Join(args, " "), req, nil
+		}
+		if count <= 0 {
+			return "", req, fmt.Errorf("purchase quantity must be positive: %d", count)
+		}
+		req.Count = count
+	}
+	return strings.Join(args[:len(args)-1], " "), req, nil
 }
 
 // SellCommand представляет команду для продажи зданий
@@ -116,8 +171,18 @@ func (c *SellCommand) Execute(player *Player, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("please specify what to sell")
 	}
-	itemName := strings.Join(args, " ")
-	return c.game.Sell(player, itemName)
+	itemName, count := strings.Join(args, " "), 1
+	if len(args) > 1 {
+		last := strings.ToLower(args[len(args)-1])
+		if last == "all" {
+			itemName = strings.Join(args[:len(args)-1], " ")
+			count = player.GetItemCount(itemName)
+		} else if n, err := strconv.Atoi(strings.TrimPrefix(last, "x")); err == nil {
+			itemName, count = strings.Join(args[:len(args)-1], " "), n
+		}
+	}
+	_, err := c.game.SellBulk(player, itemName, count)
+	return err
 }
 
 func (c *SellCommand) Name() string {
@@ -125,7 +190,7 @@ func (c *SellCommand) Name() string {
 }
 
 func (c *SellCommand) Description() string {
-	return "Sell a building"
+	return "Sell a building and get part of its cost back: sell <item> [count|all]"
 }
 
 // PrestigeCommand представляет команду для выполнения престижа
@@ -134,8 +199,15 @@ type PrestigeCommand struct {
 }
 
 func (c *PrestigeCommand) Execute(player *Player, args []string) error {
-	c.game.PerformPrestige(player)
-	return nil
+	layers := c.game.ContentSystem.GetPrestigeLayers()
+	if len(layers) == 0 {
+		return fmt.Errorf("no prestige layers configured")
+	}
+	layerID := layers[0].ID
+	if len(args) > 0 {
+		layerID = args[0]
+	}
+	return c.game.PerformPrestige(player, layerID)
 }
 
 func (c *PrestigeCommand) Name() string {
@@ -143,7 +215,7 @@ func (c *PrestigeCommand) Name() string {
 }
 
 func (c *PrestigeCommand) Description() string {
-	return "Perform a prestige reset"
+	return "Perform a prestige reset: prestige [layer]"
 }
 
 // StatusCommand представляет команду для отображения статуса игрока
@@ -152,10 +224,11 @@ type StatusCommand struct{}
 func (c *StatusCommand) Execute(player *Player, args []string) error {
 	fmt.Printf("Player: %s\n", player.ID)
 	fmt.Println("Resources:")
-	for resource, amount := range player.State.Resources {
-		fmt.Printf("%s: %.2f\n", resource, amount)
-	}
+	printAmounts(player, player.GetResources())
 	fmt.Printf("Prestige Level: %d\n", player.State.Prestige)
+	for _, layer := range player.Config.GetPrestigeLayers() {
+		fmt.Printf("  %s: %d\n", layer.Name, player.GetPrestigeLevel(layer.ID))
+	}
 	return nil
 }
 
@@ -191,9 +264,7 @@ type ListResourcesCommand struct{}
 
 func (c *ListResourcesCommand) Execute(player *Player, args []string) error {
 	fmt.Println("Your resources:")
-	for resource, amount := range player.State.Resources {
-		fmt.Printf("%s: %.2f\n", resource, amount)
-	}
+	printAmounts(player, player.GetResources())
 	return nil
 }
 
@@ -206,12 +277,25 @@ func (c *ListResourcesCommand) Description() string {
 }
 
 // ListBuildingsCommand представляет команду для отображения списка зданий игрока
-type ListBuildingsCommand struct{}
+type ListBuildingsCommand struct {
+	game *Game
+}
 
 func (c *ListBuildingsCommand) Execute(player *Player, args []string) error {
 	fmt.Println("Your buildings:")
-	for name, building := range player.State.Buildings {
-		fmt.Printf("%s: %d\n", name, building)
+	buildings := player.GetBuildings()
+	names := make([]string, 0, len(buildings))
+	for name := range buildings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cost, err := c.game.GetCost(player, name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d (next: %s)\n", name, player.GetItemCount(name), formatCost(player, cost))
 	}
 	return nil
 }
@@ -223,3 +307,405 @@ func (c *ListBuildingsCommand) Name() string {
 func (c *ListBuildingsCommand) Description() string {
 	return "List all player buildings"
 }
+
+// FormatCommand представляет команду для выбора формата отображения чисел
+type FormatCommand struct{}
+
+func (c *FormatCommand) Execute(player *Player, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("please specify notation: suffix, scientific, engineering or full")
+	}
+
+	opts := player.State.NumberFormat
+	if opts.Notation == "" {
+		opts = formatter.DefaultOptions()
+	}
+	opts.Notation = formatter.Notation(strings.ToLower(args[0]))
+	if len(args) > 1 {
+		precision, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid precision: %s", args[1])
+		}
+		opts.Precision = precision
+	}
+	if len(args) > 2 {
+		opts.Locale = strings.ToLower(args[2])
+	}
+
+	return player.SetNumberFormat(opts)
+}
+
+func (c *FormatCommand) Name() string {
+	return "Format"
+}
+
+func (c *FormatCommand) Description() string {
+	return "Set number format: format <notation> [precision] [locale]"
+}
+
+// ClaimCommand представляет команду для сбора "блестящего" объекта
+type ClaimCommand struct {
+	game *Game
+}
+
+func (c *ClaimCommand) Execute(player *Player, args []string) error {
+	if len(args) == 0 {
+		active := c.game.GetActiveShinies(player)
+		if len(active) == 0 {
+			return fmt.Errorf("nothing to claim")
+		}
+		args = active[:1]
+	}
+	return c.game.ClaimShiny(player, args[0])
+}
+
+func (c *ClaimCommand) Name() string {
+	return "Claim"
+}
+
+func (c *ClaimCommand) Description() string {
+	return "Claim an active shiny"
+}
+
+// BreakdownCommand представляет команду для отображения источников производства ресурса
+type BreakdownCommand struct {
+	game *Game
+}
+
+func (c *BreakdownCommand) Execute(player *Player, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("please specify a resource")
+	}
+	breakdown, err := c.game.GetRateBreakdown(player, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s production:\n", breakdown.Resource)
+	for _, source := range breakdown.Sources {
+		fmt.Printf("  %s x%s: +%s\n", source.Name, player.FormatNumber(source.Owned), player.FormatNumber(source.Yield))
+	}
+	fmt.Printf("  base: %s\n", player.FormatNumber(breakdown.Base))
+	for _, modifier := range breakdown.Modifiers {
+		fmt.Printf("  %s %s %v: %s -> %s\n", modifier.Source, modifier.Stage, modifier.Value,
+			player.FormatNumber(modifier.Before), player.FormatNumber(modifier.After))
+	}
+	fmt.Printf("  total: %s/s\n", player.FormatNumber(breakdown.Final))
+	if len(breakdown.Flows) == 0 {
+		return nil
+	}
+	for _, flow := range breakdown.Flows {
+		sign := "+"
+		if flow.Yield.Sign() < 0 {
+			sign = ""
+		}
+		fmt.Printf("  %s x%s: %s%s (conversion)\n", flow.Name, player.FormatNumber(flow.Owned), sign, player.FormatNumber(flow.Yield))
+	}
+	fmt.Printf("  net: %s/s\n", player.FormatNumber(breakdown.Net))
+	return nil
+}
+
+func (c *BreakdownCommand) Name() string {
+	return "Breakdown"
+}
+
+func (c *BreakdownCommand) Description() string {
+	return "Show where a resource's production comes from"
+}
+
+// printAmounts выводит значения в алфавитном порядке с учетом формата чисел игрока
+func printAmounts(player *Player, amounts map[string]bignum.Number) {
+	names := make([]string, 0, len(amounts))
+	for name := range amounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s: %s\n", name, player.FormatNumber(amounts[name]))
+	}
+}
+
+// formatCost форматирует стоимость в виде "gold 10, money 5"
+func formatCost(player *Player, cost map[string]bignum.Number) string {
+	resources := make([]string, 0, len(cost))
+	for resource := range cost {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	parts := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		parts = append(parts, fmt.Sprintf("%s %s", resource, player.FormatNumber(cost[resource])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ChallengeCommand представляет команду для управления испытаниями: challenge [list|start <id>|abandon]
+type ChallengeCommand struct {
+	game *Game
+}
+
+func (c *ChallengeCommand) Execute(player *Player, args []string) error {
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		for _, status := range c.game.GetChallenges(player) {
+			state := ""
+			if status.Active {
+				state = fmt.Sprintf(" [active %s]", status.Elapsed.Round(time.Second))
+			}
+			best := "-"
+			if status.BestTime > 0 {
+				best = status.BestTime.Round(time.Second).String()
+			}
+			fmt.Printf("%s (%s): goal %s, completed %d, best %s%s\n", status.Name, status.ID, status.Goal, status.Completions, best, state)
+		}
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "start":
+		if len(args) < 2 {
+			return fmt.Errorf("please specify a challenge")
+		}
+		return c.game.StartChallenge(player, args[1])
+	case "abandon":
+		return c.game.AbandonChallenge(player)
+	default:
+		return fmt.Errorf("unknown challenge action: %s", args[0])
+	}
+}
+
+func (c *ChallengeCommand) Name() string {
+	return "Challenge"
+}
+
+func (c *ChallengeCommand) Description() string {
+	return "Start, abandon or list challenges: challenge [list|start <id>|abandon]"
+}
+
+// AutoCommand представляет команду для управления автоматизаторами:
+// auto [list|on <id>|off <id>|strategy <id> <cheapest|reserve|below> [percent]]
+type AutoCommand struct {
+	game *Game
+}
+
+func (c *AutoCommand) Execute(player *Player, args []string) error {
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		for _, status := range c.game.GetAutomators(player) {
+			state := "locked"
+			if status.Unlocked {
+				state = "off"
+				if status.Settings.Enabled {
+					state = "on"
+				}
+			}
+			fmt.Printf("%s (%s): %s, every %s, %s %v%%, targets %s\n", status.Name, status.ID, state,
+				status.Interval, status.Settings.Strategy, status.Settings.Value, strings.Join(status.Targets, ", "))
+		}
+		return nil
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("please specify an automator")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		return c.game.SetAutomatorEnabled(player, args[1], true)
+	case "off":
+		return c.game.SetAutomatorEnabled(player, args[1], false)
+	case "strategy":
+		if len(args) < 3 {
+			return fmt.Errorf("please specify a strategy")
+		}
+		value := 0.0
+		if len(args) > 3 {
+			parsed, err := strconv.ParseFloat(strings.TrimSuffix(args[3], "%"), 64)
+			if err != nil {
+				return fmt.Errorf("invalid percentage: %s", args[3])
+			}
+			value = parsed
+		}
+		return c.game.SetAutomatorStrategy(player, args[1], AutomatorStrategy(strings.ToLower(args[2])), value)
+	default:
+		return fmt.Errorf("unknown auto action: %s", args[0])
+	}
+}
+
+func (c *AutoCommand) Name() string {
+	return "Auto"
+}
+
+func (c *AutoCommand) Description() string {
+	return "Manage automators: auto [list|on <id>|off <id>|strategy <id> <cheapest|reserve|below> [percent]]"
+}
+
+// ResearchCommand представляет команду для работы с деревом исследований: research [list|start <id>]
+type ResearchCommand struct {
+	game *Game
+}
+
+func (c *ResearchCommand) Execute(player *Player, args []string) error {
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		for _, status := range c.game.GetResearch(player) {
+			line := fmt.Sprintf("%s (%s): %s", status.Name, status.ID, status.State)
+			if status.State == ResearchInProgress {
+				line += fmt.Sprintf(", %s left", status.Remaining.Round(time.Second))
+			}
+			if len(status.Requires) > 0 {
+				line += fmt.Sprintf(", requires %s", strings.Join(status.Requires, ", "))
+			}
+			fmt.Println(line)
+		}
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "start":
+		if len(args) < 2 {
+			return fmt.Errorf("please specify a research")
+		}
+		return c.game.StartResearch(player, args[1])
+	default:
+		return fmt.Errorf("unknown research action: %s", args[0])
+	}
+}
+
+func (c *ResearchCommand) Name() string {
+	return "Research"
+}
+
+func (c *ResearchCommand) Description() string {
+	return "List or start research: research [list|start <id>]"
+}
+
+// StorageCommand представляет команду для отображения заполненности хранилищ
+type StorageCommand struct {
+	game *Game
+}
+
+func (c *StorageCommand) Execute(player *Player, args []string) error {
+	for _, status := range c.game.GetStorage(player) {
+		line := fmt.Sprintf("%s: %s / %s", status.Resource, player.FormatNumber(status.Amount), player.FormatNumber(status.Cap))
+		switch {
+		case status.Full:
+			line += " (full)"
+		case status.Filling:
+			line += fmt.Sprintf(" (full in %s)", status.TimeToFull.Round(time.Second))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func (c *StorageCommand) Name() string {
+	return "Storage"
+}
+
+func (c *StorageCommand) Description() string {
+	return "Show storage caps and time until each resource is full"
+}
+
+// StatsCommand представляет команду для отображения статистики игрока
+type StatsCommand struct {
+	game *Game
+}
+
+func (c *StatsCommand) Execute(player *Player, args []string) error {
+	stats := c.game.GetStatistics(player)
+	fmt.Printf("Time played: %s (this run: %s)\n", stats.AllTime.TimePlayed.Round(time.Second), stats.Run.TimePlayed.Round(time.Second))
+	fmt.Printf("Prestiges: %d, clicks: %d, shinies claimed: %d\n", stats.AllTime.Prestiges, stats.AllTime.Clicks, stats.AllTime.ShiniesClaimed)
+	for _, id := range c.game.ContentSystem.GetSortedIDs("resources") {
+		earned := stats.AllTime.Earned[id]
+		if earned.IsZero() {
+			continue
+		}
+		fmt.Printf("%s: earned %s (this run: %s), spent %s\n", id, player.FormatNumber(earned), player.FormatNumber(stats.Run.Earned[id]), player.FormatNumber(stats.AllTime.Spent[id]))
+	}
+	for _, id := range c.game.ContentSystem.GetSortedIDs("buildings") {
+		if bought := stats.AllTime.Bought[id]; bought > 0 {
+			fmt.Printf("%s: bought %d, sold %d, peak %d\n", id, bought, stats.AllTime.Sold[id], stats.AllTime.PeakOwned[id])
+		}
+	}
+	for _, layer := range c.game.ContentSystem.GetPrestigeLayers() {
+		if fastest, ok := stats.FastestRuns[layer.ID]; ok {
+			fmt.Printf("Fastest %s: %s\n", layer.Name, fastest.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+func (c *StatsCommand) Name() string {
+	return "Stats"
+}
+
+func (c *StatsCommand) Description() string {
+	return "Show lifetime and current run statistics"
+}
+
+// MilestonesCommand представляет команду для отображения ближайших вех предмета
+type MilestonesCommand struct {
+	game *Game
+}
+
+func (c *MilestonesCommand) Execute(player *Player, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("please specify an item")
+	}
+	upcoming, err := c.game.GetUpcomingMilestones(player, args[0])
+	if err != nil {
+		return err
+	}
+	if len(upcoming) == 0 {
+		fmt.Println("No upcoming milestones")
+	}
+	for _, status := range upcoming {
+		if status.Count > 0 {
+			fmt.Printf("%s: at %d owned (%d more)\n", status.Name, status.Count, status.Remaining)
+		} else {
+			fmt.Printf("%s: when %s\n", status.Name, status.Threshold)
+		}
+	}
+	return nil
+}
+
+func (c *MilestonesCommand) Name() string {
+	return "Milestones"
+}
+
+func (c *MilestonesCommand) Description() string {
+	return "Show upcoming milestones of an item: milestones <item>"
+}
+
+// AvailableCommand представляет команду для отображения доступных для покупки предметов
+type AvailableCommand struct {
+	game *Game
+}
+
+func (c *AvailableCommand) Execute(player *Player, args []string) error {
+	available := c.game.GetAvailableItems(player)
+	categories := make([]string, 0, len(available))
+	for category := range available {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		fmt.Printf("%s:\n", category)
+		for _, item := range available[category] {
+			if item.Visibility == VisibilityLocked {
+				fmt.Printf("  %s (locked: %s)\n", item.Name, strings.Join(item.Unmet, ", "))
+			} else {
+				fmt.Printf("  %s\n", item.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *AvailableCommand) Name() string {
+	return "Available"
+}
+
+func (c *AvailableCommand) Description() string {
+	return "List items you can buy or unlock, by category"
+}
@@ -3,10 +3,24 @@ package config
 import (
 	"gopkg.in/yaml.v2"
 	"os"
+	"time"
 )
 
 type GameConfig struct {
-	Content map[string]map[string]interface{} `yaml:"content"`
+	Settings Settings                          `yaml:"settings"`
+	Content  map[string]map[string]interface{} `yaml:"content"`
+	// Lines - номера строк исходного файла для сообщений об ошибках конфигурации
+	Lines LineIndex `yaml:"-"`
+}
+
+// Settings содержит глобальные параметры игры
+type Settings struct {
+	// MaxOfflineTime ограничивает время, за которое начисляется оффлайн-прогресс
+	MaxOfflineTime time.Duration `yaml:"max_offline_time"`
+	// TickRate - период обновления игроков движком
+	TickRate time.Duration `yaml:"tick_rate"`
+	// SellRatio - доля стоимости, возвращаемая при продаже предмета
+	SellRatio float64 `yaml:"sell_ratio"`
 }
 
 func LoadConfig(filename string) (*GameConfig, error) {
@@ -21,5 +35,6 @@ func LoadConfig(filename string) (*GameConfig, error) {
 		return nil, err
 	}
 
+	config.Lines = IndexLines(data)
 	return &config, nil
 }
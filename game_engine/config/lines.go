@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// keyPattern находит ключ словаря в начале строки: key: value, key: или "quoted key":
+var keyPattern = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s"'\[\]{}#,&*!|>%@-][^:#]*?|-[^\s:#][^:#]*?):(\s|$)`)
+
+// LineIndex сопоставляет пути YAML вида content.buildings.pan.effects[0].target номерам строк.
+// yaml.v2 не сообщает позиции узлов, поэтому индекс строится отдельным проходом по тексту
+type LineIndex map[string]int
+
+type lineFrame struct {
+	indent int
+	path   string
+}
+
+// IndexLines строит индекс строк блочного YAML: вложенных словарей и списков.
+// Элементы потоковых коллекций ([a, b]) отдельно не индексируются
+func IndexLines(source []byte) LineIndex {
+	index := make(LineIndex)
+	counters := make(map[string]int)
+	stack := make([]lineFrame, 0)
+	blockIndent := -1
+
+	for number, raw := range strings.Split(string(source), "\n") {
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" {
+			continue
+		}
+		indent := len(line) - len(content)
+		if blockIndent >= 0 {
+			if indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := ""
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].path
+		}
+
+		// Элемент списка: "- value" или "- key: value", ключи которого продолжаются ниже
+		if content == "-" || strings.HasPrefix(content, "- ") {
+			item := fmt.Sprintf("%s[%d]", parent, counters[parent])
+			counters[parent]++
+			index[item] = number + 1
+			stack = append(stack, lineFrame{indent: indent, path: item})
+
+			rest := strings.TrimLeft(strings.TrimPrefix(content, "-"), " ")
+			indent += len(content) - len(rest)
+			content, parent = rest, item
+			if content == "" {
+				continue
+			}
+		}
+
+		match := keyPattern.FindStringSubmatch(content)
+		if match == nil {
+			continue
+		}
+		key := strings.Trim(match[1], `"'`)
+		path := key
+		if parent != "" {
+			path = parent + "." + key
+		}
+		index[path] = number + 1
+		stack = append(stack, lineFrame{indent: indent, path: path})
+
+		value := strings.TrimSpace(content[len(match[0]):])
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+	}
+	return index
+}
+
+// Line возвращает номер строки пути или его ближайшего родителя; 0, если путь не найден
+func (idx LineIndex) Line(path string) int {
+	for path != "" {
+		if line, ok := idx[path]; ok {
+			return line
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			return 0
+		}
+		path = path[:cut]
+	}
+	return 0
+}
+
+// stripComment убирает комментарий, начинающийся с # вне кавычек
+func stripComment(line string) string {
+	quote := rune(0)
+	for i, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
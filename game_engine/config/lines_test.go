@@ -0,0 +1,69 @@
+package config
+
+import "testing"
+
+const indexSource = `settings:
+  tick_rate: 1s # комментарий
+content:
+  buildings:
+    pan:
+      name: "Gold # Pan"
+      description: |
+        name: не ключ
+        - не элемент
+      effects:
+        - type: yield
+          target: gold
+        -
+          type: multiply
+          target: gold
+      tags: [a, b]
+    "quoted id":
+      name: Quoted
+`
+
+func TestIndexLines(t *testing.T) {
+	index := IndexLines([]byte(indexSource))
+	tests := map[string]int{
+		"settings":                                1,
+		"settings.tick_rate":                      2,
+		"content.buildings.pan":                   5,
+		"content.buildings.pan.name":              6,
+		"content.buildings.pan.description":       7,
+		"content.buildings.pan.effects":           10,
+		"content.buildings.pan.effects[0]":        11,
+		"content.buildings.pan.effects[0].type":   11,
+		"content.buildings.pan.effects[0].target": 12,
+		"content.buildings.pan.effects[1]":        13,
+		"content.buildings.pan.effects[1].type":   14,
+		"content.buildings.pan.tags":              16,
+		"content.buildings.quoted id":             17,
+		"content.buildings.quoted id.name":        18,
+	}
+	for path, want := range tests {
+		if got := index[path]; got != want {
+			t.Errorf("%s: line %d, want %d", path, got, want)
+		}
+	}
+	for _, path := range []string{"content.buildings.pan.description.name", "content.buildings.pan.description[0]"} {
+		if line, ok := index[path]; ok {
+			t.Errorf("block scalar content indexed as %s at line %d", path, line)
+		}
+	}
+}
+
+func TestLineFallsBackToParent(t *testing.T) {
+	index := IndexLines([]byte(indexSource))
+	tests := map[string]int{
+		"content.buildings.pan.effects[0].value":  11,
+		"content.buildings.pan.effects[5].target": 10,
+		"content.buildings.pan.cost.money":        5,
+		"content.shinies.nugget":                  3,
+		"unknown.path":                            0,
+	}
+	for path, want := range tests {
+		if got := index.Line(path); got != want {
+			t.Errorf("Line(%s) = %d, want %d", path, got, want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package game_engine
 
 import (
 	"fmt"
+	"sort"
+
 	"github.com/ralist/game_engine/game_engine/config"
 )
 
@@ -12,30 +14,90 @@ type GameItem struct {
 	Name        string                 `yaml:"name"`
 	Description string                 `yaml:"description"`
 	Cost        map[string]float64     `yaml:"cost"`
+	CostScaling CostCurve              `yaml:"cost_scaling"`
 	Effects     []Effect               `yaml:"effects"`
 	Initial     int                    `yaml:"initial"`
 	Reqs        []string               `yaml:"reqs"`
+	Hidden      bool                   `yaml:"hidden"`
+	Progress    string                 `yaml:"progress"`
+	Levels      []AchievementLevel     `yaml:"levels"`
+	Milestones  []Milestone            `yaml:"milestones"`
 	Properties  map[string]interface{} `yaml:"properties"`
 }
 
 // ContentSystem управляет всем игровым контентом
 type ContentSystem struct {
-	content      map[string]map[string]GameItem
-	Items        []GameItem
-	pluginSystem *PluginSystem
+	content        map[string]map[string]GameItem
+	index          map[string]GameItem
+	sortedIDs      map[string][]string
+	prestigeLayers []PrestigeLayer
+	challenges     map[string]Challenge
+	events         map[string]LiveEvent
+	automators     map[string]Automator
+	research       map[string]ResearchNode
+	converters     []converter
+	milestones     map[string]Milestone
+	unlocks        map[string][]string
+	clock          Clock
+	Items          []GameItem
+	pluginSystem   *PluginSystem
 }
 
 // NewContentSystem создает новую систему контента на основе конфигурации игры
 func NewContentSystem(cfg *config.GameConfig) (*ContentSystem, error) {
 	cs := &ContentSystem{
 		content:      make(map[string]map[string]GameItem),
+		index:        make(map[string]GameItem),
+		sortedIDs:    make(map[string][]string),
 		pluginSystem: NewPluginSystem(),
 	}
 
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+
 	if err := cs.parseContent(cfg.Content); err != nil {
 		return nil, fmt.Errorf("failed to parse content: %w", err)
 	}
 
+	layers, err := parsePrestigeLayers(cs.content["prestige"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prestige layers: %w", err)
+	}
+	cs.prestigeLayers = layers
+
+	challenges, err := parseChallenges(cs.content["challenges"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse challenges: %w", err)
+	}
+	cs.challenges = challenges
+
+	events, err := parseEvents(cs.content["events"], cs.index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse events: %w", err)
+	}
+	cs.events = events
+
+	automators, err := parseAutomators(cs.content["automators"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse automators: %w", err)
+	}
+	cs.automators = automators
+
+	research, err := parseResearch(cs.content["research"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse research: %w", err)
+	}
+	cs.research = research
+	cs.converters = orderConverters(cs.Items)
+
+	milestones, unlocks, err := indexMilestones(cs.Items, cs.index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse milestones: %w", err)
+	}
+	cs.milestones = milestones
+	cs.unlocks = unlocks
+
 	return cs, nil
 }
 
@@ -55,7 +117,10 @@ func (cs *ContentSystem) parseContent(content map[string]map[string]interface{})
 			}
 			cs.Items = append(cs.Items, item)
 			cs.content[category][name] = item
+			cs.index[name] = item
+			cs.sortedIDs[category] = append(cs.sortedIDs[category], name)
 		}
+		sort.Strings(cs.sortedIDs[category])
 	}
 	return nil
 }
@@ -80,6 +145,18 @@ func convertMapInterfaceToMapString(m map[interface{}]interface{}) map[string]in
 	return result
 }
 
+// toFloat преобразует числовое значение из YAML в float64
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
 // convertSliceInterfaceToSliceString преобразует []interface{} рекурсивно
 func convertSliceInterfaceToSliceString(slice []interface{}) []interface{} {
 	result := make([]interface{}, len(slice))
@@ -127,32 +204,17 @@ func (cs *ContentSystem) createContentItem(name string, data map[string]interfac
 		delete(data, "cost")
 	}
 
-	if effects, ok := data["effects"].([]interface{}); ok {
-		item.Effects = make([]Effect, 0, len(effects))
-		for _, effect := range effects {
-			if effectMap, ok := effect.(map[string]interface{}); ok {
-				newEffect := Effect{}
-				if typeStr, ok := effectMap["type"].(string); ok {
-					newEffect.Type = typeStr
-				}
-				if target, ok := effectMap["target"].(string); ok {
-					newEffect.Target = target
-				}
-				if value, ok := effectMap["value"].(float64); ok {
-					newEffect.Value = value
-				} else if intValue, ok := effectMap["value"].(int); ok {
-					newEffect.Value = float64(intValue)
-				}
-				if expression, ok := effectMap["expression"].(string); ok {
-					newEffect.Expression = expression
-				}
-				if condition, ok := effectMap["condition"].(string); ok {
-					newEffect.Condition = condition
-				}
-				item.Effects = append(item.Effects, newEffect)
-			}
+	if scaling, ok := data["cost_scaling"].(map[string]interface{}); ok {
+		curve, err := parseCostCurve(scaling)
+		if err != nil {
+			return GameItem{}, fmt.Errorf("invalid cost scaling: %w", err)
 		}
+		item.CostScaling = curve
+		delete(data, "cost_scaling")
+	}
 
+	if effects, ok := data["effects"].([]interface{}); ok {
+		item.Effects = parseEffects(effects)
 		delete(data, "effects")
 	}
 
@@ -172,10 +234,79 @@ func (cs *ContentSystem) createContentItem(name string, data map[string]interfac
 		delete(data, "reqs")
 	}
 
+	if hidden, ok := data["hidden"].(bool); ok {
+		item.Hidden = hidden
+		delete(data, "hidden")
+	}
+
+	if progress, ok := data["progress"].(string); ok {
+		item.Progress = progress
+		delete(data, "progress")
+	}
+
+	if levels, ok := data["levels"].([]interface{}); ok {
+		parsed, err := parseAchievementLevels(levels)
+		if err != nil {
+			return GameItem{}, fmt.Errorf("invalid achievement levels: %w", err)
+		}
+		item.Levels = parsed
+		delete(data, "levels")
+	}
+
+	if milestones, ok := data["milestones"].([]interface{}); ok {
+		parsed, err := parseMilestones(name, milestones)
+		if err != nil {
+			return GameItem{}, fmt.Errorf("invalid milestones: %w", err)
+		}
+		item.Milestones = parsed
+		delete(data, "milestones")
+	}
+
 	item.Properties = data
 	return item, nil
 }
 
+// parseEffects разбирает список эффектов из YAML
+func parseEffects(effects []interface{}) []Effect {
+	parsed := make([]Effect, 0, len(effects))
+	for _, effect := range effects {
+		if effectMap, ok := effect.(map[string]interface{}); ok {
+			newEffect := Effect{}
+			if typeStr, ok := effectMap["type"].(string); ok {
+				newEffect.Type = typeStr
+			}
+			if target, ok := effectMap["target"].(string); ok {
+				newEffect.Target = target
+			}
+			if value, ok := effectMap["value"].(float64); ok {
+				newEffect.Value = value
+			} else if intValue, ok := effectMap["value"].(int); ok {
+				newEffect.Value = float64(intValue)
+			}
+			if expression, ok := effectMap["expression"].(string); ok {
+				newEffect.Expression = expression
+			}
+			if condition, ok := effectMap["condition"].(string); ok {
+				newEffect.Condition = condition
+			}
+			if id, ok := effectMap["id"].(string); ok {
+				newEffect.ID = id
+			}
+			if duration, ok := effectMap["duration"]; ok {
+				newEffect.Duration = toFloat(duration)
+			}
+			if stacking, ok := effectMap["stacking"].(string); ok {
+				newEffect.Stacking = stacking
+			}
+			newEffect.Inputs = toFloatMap(effectMap["inputs"])
+			newEffect.Outputs = toFloatMap(effectMap["outputs"])
+			newEffect.Priority = int(toFloat(effectMap["priority"]))
+			parsed = append(parsed, newEffect)
+		}
+	}
+	return parsed
+}
+
 // GetContent возвращает элемент контента по категории и имени
 func (cs *ContentSystem) GetContent(category, name string) (GameItem, error) {
 	categoryContent, ok := cs.content[category]
@@ -189,6 +320,17 @@ func (cs *ContentSystem) GetContent(category, name string) (GameItem, error) {
 	return item, nil
 }
 
+// GetItem возвращает элемент контента по ID независимо от категории
+func (cs *ContentSystem) GetItem(id string) (GameItem, bool) {
+	item, ok := cs.index[id]
+	return item, ok
+}
+
+// GetSortedIDs возвращает ID элементов категории в алфавитном порядке
+func (cs *ContentSystem) GetSortedIDs(category string) []string {
+	return cs.sortedIDs[category]
+}
+
 // GetAllContent возвращает все элементы контента в указанной категории
 func (cs *ContentSystem) GetAllContent(category string) map[string]GameItem {
 	return cs.content[category]
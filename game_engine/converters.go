@@ -0,0 +1,162 @@
+package game_engine
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// converter - эффект convert предмета: каждая единица предмета за секунду тратит Inputs
+// и производит Outputs. Модификаторы производства на результат переработки не действуют
+type converter struct {
+	ItemID string
+	Index  int
+	Effect Effect
+}
+
+// orderConverters упорядочивает переработчики так, чтобы поставщик ресурса работал раньше
+// его потребителя (руда -> слиток -> монета). Независимые переработчики идут по priority,
+// затем по ID предмета; переработчики, замкнутые в цикл, - в том же порядке после остальных
+func orderConverters(items []GameItem) []converter {
+	converters := make([]converter, 0)
+	for _, item := range items {
+		for i, effect := range item.Effects {
+			if effect.Type == "convert" {
+				converters = append(converters, converter{ItemID: item.ID, Index: i, Effect: effect})
+			}
+		}
+	}
+	sort.SliceStable(converters, func(i, j int) bool {
+		a, b := converters[i], converters[j]
+		if a.Effect.Priority != b.Effect.Priority {
+			return a.Effect.Priority < b.Effect.Priority
+		}
+		if a.ItemID != b.ItemID {
+			return a.ItemID < b.ItemID
+		}
+		return a.Index < b.Index
+	})
+
+	// i зависит от j, если j производит то, что тратит i
+	pending := make([]int, len(converters))
+	for i, consumer := range converters {
+		for j, producer := range converters {
+			if i != j && feeds(producer.Effect, consumer.Effect) {
+				pending[i]++
+			}
+		}
+	}
+
+	ordered := make([]converter, 0, len(converters))
+	done := make([]bool, len(converters))
+	for len(ordered) < len(converters) {
+		next := -1
+		for i := range converters {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			// Цикл: берем первый оставшийся по порядку приоритетов
+			for i := range converters {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+		done[next] = true
+		ordered = append(ordered, converters[next])
+		for i, consumer := range converters {
+			if !done[i] && feeds(converters[next].Effect, consumer.Effect) {
+				pending[i]--
+			}
+		}
+	}
+	return ordered
+}
+
+// feeds проверяет, производит ли producer ресурс, который тратит consumer
+func feeds(producer, consumer Effect) bool {
+	for resource := range producer.Outputs {
+		if _, ok := consumer.Inputs[resource]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// convert запускает переработчики игрока за промежуток dt. Если входных ресурсов
+// не хватает на полную мощность или выпуску некуда поместиться, переработчик
+// работает на доступную долю
+func (g *Game) convert(player *Player, dt time.Duration) {
+	for _, c := range g.ContentSystem.converters {
+		item := player.State.Items[c.ItemID]
+		if item == nil || item.Amount.Sign() <= 0 {
+			continue
+		}
+
+		cycles := item.Amount.MulFloat(dt.Seconds())
+		throughput := 1.0
+		for resource, ratio := range c.Effect.Inputs {
+			need := cycles.MulFloat(ratio)
+			if need.Sign() <= 0 {
+				continue
+			}
+			have := player.GetItemAmount(resource)
+			if have.Sign() <= 0 {
+				throughput = 0
+				break
+			}
+			throughput = math.Min(throughput, have.Div(need).Float64())
+		}
+		// Выпуск в заполненное хранилище тоже ограничивает загрузку
+		for resource, ratio := range c.Effect.Outputs {
+			space, capped := player.freeSpace(resource)
+			if output := cycles.MulFloat(ratio); capped && output.Sign() > 0 {
+				throughput = math.Min(throughput, space.Div(output).Float64())
+			}
+		}
+		if throughput <= 0 {
+			continue
+		}
+
+		cycles = cycles.MulFloat(throughput)
+		for resource, ratio := range c.Effect.Inputs {
+			if input := player.State.Items[resource]; input != nil {
+				input.Amount = bignum.Max(input.Amount.Sub(cycles.MulFloat(ratio)), bignum.Zero())
+			}
+		}
+		for resource, ratio := range c.Effect.Outputs {
+			if player.GetItem(resource) == nil {
+				log.Printf("Converter %s outputs unknown resource %s", c.ItemID, resource)
+				continue
+			}
+			player.AddItem(resource, cycles.MulFloat(ratio))
+			player.creditItem(c.ItemID, resource, cycles.MulFloat(ratio))
+		}
+	}
+}
+
+// addConverterFlows дописывает в разбивку номинальный расход и выпуск переработчиков
+func (p *Player) addConverterFlows(breakdowns map[string]*RateBreakdown) {
+	if p.Config == nil {
+		return
+	}
+	for _, c := range p.Config.converters {
+		item := p.State.Items[c.ItemID]
+		if item == nil || item.Amount.Sign() <= 0 {
+			continue
+		}
+		for resource, ratio := range c.Effect.Inputs {
+			breakdownFor(breakdowns, resource).addFlow(item, item.Amount.MulFloat(-ratio))
+		}
+		for resource, ratio := range c.Effect.Outputs {
+			breakdownFor(breakdowns, resource).addFlow(item, item.Amount.MulFloat(ratio))
+		}
+	}
+}
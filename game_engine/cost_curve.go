@@ -0,0 +1,170 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// Модели роста стоимости предмета
+const (
+	CostLinear      = "linear"
+	CostExponential = "exponential"
+	CostPolynomial  = "polynomial"
+	CostStep        = "step"
+	CostExpression  = "expression"
+)
+
+// CostCurve описывает, как растет стоимость предмета с количеством купленных единиц
+type CostCurve struct {
+	Type       string     `yaml:"type"`
+	Rate       float64    `yaml:"rate"`
+	Power      float64    `yaml:"power"`
+	Steps      []CostTier `yaml:"steps"`
+	Expression string     `yaml:"expression"`
+}
+
+// CostTier - ступень таблицы стоимости: начиная с Owned купленных единиц цена умножается на Multiplier
+type CostTier struct {
+	Owned      int     `yaml:"owned"`
+	Multiplier float64 `yaml:"multiplier"`
+}
+
+// parseCostCurve создает модель стоимости из секции cost_scaling конфигурации
+func parseCostCurve(data map[string]interface{}) (CostCurve, error) {
+	curve := CostCurve{Type: CostLinear}
+	if typeStr, ok := data["type"].(string); ok {
+		curve.Type = typeStr
+	}
+	curve.Rate = toFloat(data["rate"])
+	curve.Power = toFloat(data["power"])
+	if expression, ok := data["expression"].(string); ok {
+		curve.Expression = expression
+	}
+	if steps, ok := data["steps"].([]interface{}); ok {
+		for _, step := range steps {
+			stepMap, ok := step.(map[string]interface{})
+			if !ok {
+				return CostCurve{}, fmt.Errorf("invalid cost step: %v", step)
+			}
+			curve.Steps = append(curve.Steps, CostTier{
+				Owned:      int(toFloat(stepMap["owned"])),
+				Multiplier: toFloat(stepMap["multiplier"]),
+			})
+		}
+		sort.Slice(curve.Steps, func(i, j int) bool { return curve.Steps[i].Owned < curve.Steps[j].Owned })
+	}
+
+	switch curve.Type {
+	case CostLinear:
+	case CostExponential:
+		if curve.Rate <= 0 {
+			return CostCurve{}, fmt.Errorf("exponential cost scaling requires a positive rate")
+		}
+	case CostPolynomial:
+		if curve.Power <= 0 {
+			return CostCurve{}, fmt.Errorf("polynomial cost scaling requires a positive power")
+		}
+	case CostStep:
+		if len(curve.Steps) == 0 {
+			return CostCurve{}, fmt.Errorf("step cost scaling requires at least one step")
+		}
+	case CostExpression:
+		if curve.Expression == "" {
+			return CostCurve{}, fmt.Errorf("expression cost scaling requires an expression")
+		}
+	default:
+		return CostCurve{}, fmt.Errorf("unknown cost scaling type: %s", curve.Type)
+	}
+
+	return curve, nil
+}
+
+// Multiplier возвращает множитель базовой стоимости для покупки единицы номер owned+1
+func (c CostCurve) Multiplier(owned int) bignum.Number {
+	switch c.Type {
+	case CostExponential:
+		return bignum.FromFloat(c.Rate).Pow(float64(owned))
+	case CostPolynomial:
+		return bignum.FromInt(owned + 1).Pow(c.Power)
+	case CostStep:
+		multiplier := 1.0
+		for _, step := range c.Steps {
+			if owned < step.Owned {
+				break
+			}
+			multiplier = step.Multiplier
+		}
+		return bignum.FromFloat(multiplier)
+	default:
+		return bignum.FromInt(owned + 1)
+	}
+}
+
+// calculateCost возвращает стоимость следующей единицы предмета, если у игрока уже есть owned единиц.
+// Формулы стоимости вычисляются на снимке параметров evaluator
+func (g *Game) calculateCost(player *Player, evaluator *ExpressionEvaluator, itemID string, owned int) map[string]bignum.Number {
+	baseCost, curve := g.costModel(player, itemID)
+	return curveCost(evaluator, itemID, baseCost, curve, owned)
+}
+
+// curveCost возвращает стоимость единицы номер owned+1 по базовой стоимости baseCost и модели роста curve
+func curveCost(evaluator *ExpressionEvaluator, itemID string, baseCost map[string]float64, curve CostCurve, owned int) map[string]bignum.Number {
+	cost := make(map[string]bignum.Number, len(baseCost))
+	for resource, amount := range baseCost {
+		if curve.Type != CostExpression {
+			cost[resource] = bignum.FromFloat(amount).Mul(curve.Multiplier(owned))
+			continue
+		}
+
+		value, err := evaluator.WithParams(map[string]interface{}{
+			"owned": float64(owned),
+			"base":  amount,
+		}).Evaluate(curve.Expression)
+		if err != nil {
+			// Без корректной цены предмет нельзя купить: CanAfford отвергает нулевую стоимость
+			log.Printf("Error evaluating cost expression for %s: %v", itemID, err)
+			value = 0
+		}
+		cost[resource] = bignum.FromFloat(value)
+	}
+	return cost
+}
+
+// GetCost возвращает стоимость следующей единицы предмета для игрока
+func (g *Game) GetCost(player *Player, itemID string) (map[string]bignum.Number, error) {
+	if player.GetItem(itemID) == nil {
+		return nil, fmt.Errorf("item not found: %s", itemID)
+	}
+	return g.calculateCost(player, NewExpressionEvaluator(player), itemID, player.GetItemCount(itemID)), nil
+}
+
+// costModel возвращает базовую стоимость с учетом эффектов cost и штрафов испытания
+// и модель роста для предмета
+func (g *Game) costModel(player *Player, itemID string) (map[string]float64, CostCurve) {
+	var baseCost map[string]float64
+	var itemType string
+	curve := CostCurve{Type: CostLinear}
+	if item, ok := g.ContentSystem.GetItem(itemID); ok {
+		baseCost, itemType, curve = item.Cost, item.Type, item.CostScaling
+	} else if item := player.GetItem(itemID); item != nil {
+		baseCost, itemType = item.Cost, item.Type
+	} else {
+		return nil, curve
+	}
+
+	multiplier := player.costMultiplier(itemID, itemType)
+	if challenge, ok := player.activeChallenge(); ok {
+		multiplier *= challenge.Restrictions.costMultiplier(itemID, itemType)
+	}
+	if multiplier == 1 {
+		return baseCost, curve
+	}
+	cost := make(map[string]float64, len(baseCost))
+	for resource, amount := range baseCost {
+		cost[resource] = amount * multiplier
+	}
+	return cost, curve
+}
@@ -1,8 +1,11 @@
 package game_engine
 
 import (
+	"fmt"
 	"log"
 	"math/rand"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
 )
 
 type Effect struct {
@@ -11,6 +14,14 @@ type Effect struct {
 	Value      float64 `yaml:"value"`
 	Expression string  `yaml:"expression"`
 	Condition  string  `yaml:"condition"`
+	// ID, Duration (в секундах) и Stacking используются эффектом boost
+	ID       string  `yaml:"id"`
+	Duration float64 `yaml:"duration"`
+	Stacking string  `yaml:"stacking"`
+	// Inputs, Outputs и Priority используются эффектом convert
+	Inputs   map[string]float64 `yaml:"inputs"`
+	Outputs  map[string]float64 `yaml:"outputs"`
+	Priority int                `yaml:"priority"`
 }
 
 type EffectBlock struct {
@@ -22,12 +33,18 @@ func (g *Game) applyEffect(player *Player, effect Effect) {
 	switch effect.Type {
 	case "yield":
 		g.applyYieldEffect(player, effect)
-	case "multiply":
-		g.applyMultiplyEffect(player, effect)
+	case "add", "multiply", "exponent":
+		g.applyModifierEffect(player, effect)
 	case "grant":
 		g.applyGrantEffect(player, effect)
 	case "spawn":
 		g.applySpawnEffect(player, effect)
+	case "convert":
+		// Переработчики работают каждый тик, пока предмет принадлежит игроку
+	case "boost":
+		g.applyBoostEffect(player, effect, g.boostStart(player))
+	case EffectCost, EffectOfflineCap, EffectStarting, EffectCap, EffectUnlock:
+		// Пассивные эффекты учитываются, пока предмет принадлежит игроку
 	default:
 		log.Printf("Unknown effect type: %s", effect.Type)
 	}
@@ -39,21 +56,37 @@ func (g *Game) applyYieldEffect(player *Player, effect Effect) {
 		log.Printf("Error evaluating yield expression: %v", err)
 		return
 	}
-	player.AddItem(effect.Target, amount)
+	player.AddItem(effect.Target, bignum.FromFloat(amount))
 }
 
-func (g *Game) applyMultiplyEffect(player *Player, effect Effect) {
-	currentAmount := player.State.Resources[effect.Target]
-	newAmount := float64(currentAmount) * effect.Value
-	player.State.Resources[effect.Target] = uint64(newAmount)
+func (g *Game) applyModifierEffect(player *Player, effect Effect) {
+	value, err := effectValue(player, effect)
+	if err != nil {
+		log.Printf("Error evaluating %s expression: %v", effect.Type, err)
+		return
+	}
+	player.AddModifier(Modifier{
+		Source: "effect",
+		Target: effect.Target,
+		Stage:  ModifierStage(effect.Type),
+		Value:  value,
+	})
 }
 
 func (g *Game) applyGrantEffect(player *Player, effect Effect) {
-	player.SetShinyState(effect.Target, ShinyState{Active: true, LastSpawn: player.State.LastSaveTime})
+	amount, err := effectValue(player, effect)
+	if err != nil {
+		log.Printf("Error evaluating grant expression: %v", err)
+		return
+	}
+	player.AddItem(effect.Target, bignum.FromFloat(amount))
+	player.AddLog(fmt.Sprintf("Received %s %s", player.FormatNumber(bignum.FromFloat(amount)), effect.Target))
 }
 
 func (g *Game) applySpawnEffect(player *Player, effect Effect) {
-	// Implement spawn effect logic here
+	if err := g.SpawnShiny(player, effect.Target); err != nil {
+		log.Printf("Error spawning shiny: %v", err)
+	}
 }
 
 func (g *Game) executeEffectBlock(player *Player, block EffectBlock) {
@@ -0,0 +1,339 @@
+package game_engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// LiveEvent - ограниченное по времени событие из категории events. Предметы любой категории
+// со свойством event: <id> (включая валюту события) становятся контентом события
+// и доступны только пока оно идет
+type LiveEvent struct {
+	ID       string
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Currency string
+	// ConvertTo - ресурс, в который обменивается валюта события по курсу ConversionRate
+	// после окончания. Если не задан, валюта сгорает
+	ConvertTo      string
+	ConversionRate float64
+	Rewards        []EventReward
+	Content        []string
+}
+
+// EventReward - ступень наградной шкалы: выдается, когда за событие заработано Points валюты
+type EventReward struct {
+	Points  float64
+	Effects []Effect
+}
+
+// EventState - участие игрока в событии
+type EventState struct {
+	Joined time.Time `json:"joined"`
+	// Baseline - заработок валюты события на момент входа, от него считается прогресс шкалы
+	Baseline bignum.Number `json:"baseline"`
+	Rewards  int           `json:"rewards"`
+	Ended    bool          `json:"ended"`
+}
+
+// EventStatus описывает событие для интерфейса
+type EventStatus struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Start      time.Time     `json:"start"`
+	End        time.Time     `json:"end"`
+	Active     bool          `json:"active"`
+	Remaining  time.Duration `json:"remaining,omitempty"`
+	Points     bignum.Number `json:"points"`
+	Rewards    int           `json:"rewards"`
+	NextReward float64       `json:"nextReward,omitempty"`
+}
+
+// ActiveAt проверяет, идет ли событие в момент now
+func (e LiveEvent) ActiveAt(now time.Time) bool {
+	return !now.Before(e.Start) && now.Before(e.End)
+}
+
+// parseEvents создает события из категории events и собирает их контент
+func parseEvents(items map[string]GameItem, index map[string]GameItem) (map[string]LiveEvent, error) {
+	events := make(map[string]LiveEvent, len(items))
+	for id, item := range items {
+		event := LiveEvent{
+			ID:             id,
+			Name:           item.Name,
+			ConversionRate: 1,
+		}
+
+		var err error
+		if event.Start, err = toTime(item.Properties["start"]); err != nil {
+			return nil, fmt.Errorf("invalid start of event %s: %w", id, err)
+		}
+		if event.End, err = toTime(item.Properties["end"]); err != nil {
+			return nil, fmt.Errorf("invalid end of event %s: %w", id, err)
+		}
+		if !event.End.After(event.Start) {
+			return nil, fmt.Errorf("event %s ends before it starts", id)
+		}
+
+		if currency, ok := item.Properties["currency"].(string); ok {
+			event.Currency = currency
+		}
+		if convertTo, ok := item.Properties["convert_to"].(string); ok {
+			event.ConvertTo = convertTo
+		}
+		if rate, ok := item.Properties["conversion_rate"]; ok {
+			event.ConversionRate = toFloat(rate)
+		}
+
+		if rewards, ok := item.Properties["rewards"].([]interface{}); ok {
+			for i, raw := range rewards {
+				reward, ok := raw.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("reward %d of event %s must be a map", i, id)
+				}
+				effects, _ := reward["effects"].([]interface{})
+				event.Rewards = append(event.Rewards, EventReward{
+					Points:  toFloat(reward["points"]),
+					Effects: parseEffects(effects),
+				})
+			}
+			sort.SliceStable(event.Rewards, func(i, j int) bool {
+				return event.Rewards[i].Points < event.Rewards[j].Points
+			})
+		}
+
+		events[id] = event
+	}
+
+	for id, item := range index {
+		eventID, ok := item.Properties["event"].(string)
+		if !ok {
+			continue
+		}
+		event, ok := events[eventID]
+		if !ok {
+			return nil, fmt.Errorf("item %s refers to unknown event %s", id, eventID)
+		}
+		event.Content = append(event.Content, id)
+		events[eventID] = event
+	}
+	for id, event := range events {
+		sort.Strings(event.Content)
+		events[id] = event
+	}
+	return events, nil
+}
+
+// toTime разбирает время из YAML: RFC 3339 строку или уже разобранную метку времени
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(time.RFC3339, v)
+	default:
+		return time.Time{}, fmt.Errorf("expected RFC 3339 time, got %v", value)
+	}
+}
+
+// SetClock задает часы, по которым включается и выключается контент событий
+func (cs *ContentSystem) SetClock(clock Clock) {
+	cs.clock = clock
+}
+
+// GetEvent возвращает событие по ID
+func (cs *ContentSystem) GetEvent(id string) (LiveEvent, error) {
+	event, ok := cs.events[id]
+	if !ok {
+		return LiveEvent{}, fmt.Errorf("event not found: %s", id)
+	}
+	return event, nil
+}
+
+// ActiveEvents возвращает события, которые идут по часам системы контента
+func (cs *ContentSystem) ActiveEvents() []LiveEvent {
+	return cs.activeEventsAt(cs.now())
+}
+
+func (cs *ContentSystem) activeEventsAt(now time.Time) []LiveEvent {
+	active := make([]LiveEvent, 0)
+	for _, id := range cs.GetSortedIDs("events") {
+		if event := cs.events[id]; event.ActiveAt(now) {
+			active = append(active, event)
+		}
+	}
+	return active
+}
+
+// IsAvailable проверяет, доступен ли предмет сейчас: контент события доступен, только пока оно идет
+func (cs *ContentSystem) IsAvailable(itemID string) bool {
+	item, ok := cs.GetItem(itemID)
+	if !ok {
+		return true
+	}
+	eventID, ok := item.Properties["event"].(string)
+	if !ok {
+		return true
+	}
+	return cs.events[eventID].ActiveAt(cs.now())
+}
+
+// now возвращает время по часам игры; до подключения часов - системное время
+func (cs *ContentSystem) now() time.Time {
+	if cs.clock == nil {
+		return time.Now()
+	}
+	return cs.clock.Now()
+}
+
+// eventTick - этап тика, подключающий игрока к начавшимся событиям, выдающий награды
+// шкалы и завершающий закончившиеся события
+func (g *Game) eventTick(player *Player, now time.Time, dt time.Duration) {
+	for _, id := range g.ContentSystem.GetSortedIDs("events") {
+		event, err := g.ContentSystem.GetEvent(id)
+		if err != nil {
+			continue
+		}
+		state, joined := player.State.Events[id]
+		// Состояние прошлого проведения события с тем же ID не переносится
+		if joined && state.Joined.Before(event.Start) {
+			joined = false
+		}
+
+		switch {
+		case event.ActiveAt(now) && !joined:
+			g.joinEvent(player, event, now)
+		case event.ActiveAt(now):
+			g.checkEventRewards(player, event)
+		case joined && !state.Ended && !now.Before(event.End):
+			g.checkEventRewards(player, event)
+			g.endEvent(player, event)
+		}
+	}
+}
+
+// joinEvent подключает игрока к событию. Игроки, пришедшие позже начала или сохраненные
+// до появления события в конфигурации, получают контент события с начальными значениями
+func (g *Game) joinEvent(player *Player, event LiveEvent, now time.Time) {
+	for _, id := range event.Content {
+		item, ok := g.ContentSystem.GetItem(id)
+		if !ok {
+			continue
+		}
+		if existing := player.State.Items[id]; existing != nil {
+			existing.Amount = bignum.FromInt(item.Initial)
+			continue
+		}
+		player.State.Items[id] = newPlayerItem(item)
+	}
+
+	player.State.Events[event.ID] = EventState{
+		Joined:   now,
+		Baseline: player.State.Stats.AllTime.Earned[event.Currency],
+	}
+	player.RecalculateState()
+
+	player.AddLog(fmt.Sprintf("Event started: %s", event.Name))
+	g.EventSystem.Emit("LiveEventJoined", map[string]interface{}{
+		"PlayerID": player.ID,
+		"EventID":  event.ID,
+	})
+}
+
+// eventPoints возвращает валюту, заработанную игроком за текущее проведение события
+func (p *Player) eventPoints(event LiveEvent) bignum.Number {
+	if event.Currency == "" {
+		return bignum.Zero()
+	}
+	return p.State.Stats.AllTime.Earned[event.Currency].Sub(p.State.Events[event.ID].Baseline)
+}
+
+// checkEventRewards выдает все достигнутые ступени наградной шкалы
+func (g *Game) checkEventRewards(player *Player, event LiveEvent) {
+	state := player.State.Events[event.ID]
+	points := player.eventPoints(event)
+	granted := false
+	for state.Rewards < len(event.Rewards) {
+		reward := event.Rewards[state.Rewards]
+		if points.Lt(bignum.FromFloat(reward.Points)) {
+			break
+		}
+		state.Rewards++
+		g.grantPermanentEffects(player, fmt.Sprintf("%s:%d", event.ID, state.Rewards), reward.Effects)
+		player.AddLog(fmt.Sprintf("Event reward unlocked: %s %d", event.Name, state.Rewards))
+		g.EventSystem.Emit("LiveEventReward", map[string]interface{}{
+			"PlayerID": player.ID,
+			"EventID":  event.ID,
+			"Reward":   state.Rewards,
+		})
+		granted = true
+	}
+	player.State.Events[event.ID] = state
+	if granted {
+		player.RecalculateState()
+	}
+}
+
+// endEvent убирает временный контент события и обменивает или сжигает его валюту
+func (g *Game) endEvent(player *Player, event LiveEvent) {
+	if currency := player.State.Items[event.Currency]; currency != nil {
+		if event.ConvertTo != "" && currency.Amount.Sign() > 0 {
+			converted := currency.Amount.MulFloat(event.ConversionRate).Floor()
+			player.AddItem(event.ConvertTo, converted)
+			player.AddLog(fmt.Sprintf("Event currency converted: %s %s", player.FormatNumber(converted), event.ConvertTo))
+		}
+		currency.Amount = bignum.Zero()
+	}
+	for _, id := range event.Content {
+		if item := player.State.Items[id]; item != nil {
+			item.Amount = bignum.Zero()
+		}
+	}
+
+	state := player.State.Events[event.ID]
+	state.Ended = true
+	player.State.Events[event.ID] = state
+	player.RecalculateState()
+
+	player.AddLog(fmt.Sprintf("Event ended: %s", event.Name))
+	g.EventSystem.Emit("LiveEventEnded", map[string]interface{}{
+		"PlayerID": player.ID,
+		"EventID":  event.ID,
+	})
+}
+
+// GetEvents возвращает состояние всех событий для игрока по часам игры
+func (g *Game) GetEvents(player *Player) []EventStatus {
+	now := g.Clock.Now()
+	ids := g.ContentSystem.GetSortedIDs("events")
+	statuses := make([]EventStatus, 0, len(ids))
+	for _, id := range ids {
+		event, err := g.ContentSystem.GetEvent(id)
+		if err != nil {
+			continue
+		}
+		status := EventStatus{
+			ID:     id,
+			Name:   event.Name,
+			Start:  event.Start,
+			End:    event.End,
+			Active: event.ActiveAt(now),
+		}
+		if state, ok := player.State.Events[id]; ok && !state.Joined.Before(event.Start) {
+			status.Points = player.eventPoints(event)
+			status.Rewards = state.Rewards
+		}
+		if status.Active {
+			status.Remaining = event.End.Sub(now)
+		}
+		if status.Rewards < len(event.Rewards) {
+			status.NextReward = event.Rewards[status.Rewards].Points
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/Knetic/govaluate"
 )
@@ -12,18 +14,36 @@ import (
 type ExpressionEvaluator struct {
 	player *Player
 	game   *Game
+	extra  map[string]interface{}
+	params map[string]interface{}
 }
 
-func NewExpressionEvaluator(player *Player) *ExpressionEvaluator {
-	return &ExpressionEvaluator{
-		player: player,
-	}
-}
-
-func (ee *ExpressionEvaluator) Evaluate(expression string) (float64, error) {
-	functions := map[string]govaluate.ExpressionFunction{
-		"have":    ee.have(ee.player),
-		"no":      ee.no(ee.player),
+// havePattern находит вызовы have('id') и no('id'), которые заменяются параметрами [have:id] и [no:id].
+// Функции govaluate привязываются к выражению при разборе, и have/no, замкнутые на игрока,
+// заставляли бы разбирать выражение заново для каждого игрока. Параметры have:/no: вычисляются
+// через Player.Has в момент вычисления (см. expressionParameters), поэтому результат тот же,
+// что и у прежних функций, а разобранное выражение можно кэшировать
+var havePattern = regexp.MustCompile(`\b(have|no)\(\s*['"]([^'"]+)['"]\s*\)`)
+
+// compiledExpressions кэширует разобранные выражения: разбор заметно дороже вычисления.
+// Разобранное выражение не зависит ни от игрока, ни от игры, поэтому кэш общий для всех игр
+var compiledExpressions = struct {
+	sync.RWMutex
+	m map[string]*govaluate.EvaluableExpression
+}{m: make(map[string]*govaluate.EvaluableExpression)}
+
+// maxCompiledExpressions ограничивает кэш разобранных выражений. Выражения конфигурации
+// укладываются в предел с запасом; если выражения собираются на лету и кэш переполняется,
+// он сбрасывается целиком
+const maxCompiledExpressions = 4096
+
+var expressionFunctions = newExpressionFunctions()
+
+func newExpressionFunctions() map[string]govaluate.ExpressionFunction {
+	ee := &ExpressionEvaluator{}
+	return map[string]govaluate.ExpressionFunction{
+		"have":    ee.have,
+		"no":      ee.no,
 		"random":  ee.random,
 		"frandom": ee.frandom,
 		"chance":  ee.chance,
@@ -37,15 +57,41 @@ func (ee *ExpressionEvaluator) Evaluate(expression string) (float64, error) {
 		"and":     ee.and,
 		"or":      ee.or,
 	}
+}
 
-	prsExpr, err := ee.preprocessExpression(expression)
-	expr, err := govaluate.NewEvaluableExpressionWithFunctions(prsExpr, functions)
+// NewExpressionEvaluator создает вычислитель выражений для игрока. Параметры игрока
+// снимаются при первом вычислении и переиспользуются последующими вызовами Evaluate,
+// поэтому после изменения состояния игрока нужен новый вычислитель
+func NewExpressionEvaluator(player *Player) *ExpressionEvaluator {
+	return &ExpressionEvaluator{
+		player: player,
+	}
+}
+
+// WithParams возвращает вычислитель с дополнительными параметрами (например, owned для формул
+// стоимости). Снимок параметров игрока общий с исходным вычислителем и не снимается заново
+func (ee *ExpressionEvaluator) WithParams(extra map[string]interface{}) *ExpressionEvaluator {
+	if ee.params == nil {
+		ee.params = ee.getParameters(ee.player, ee.game)
+	}
+	return &ExpressionEvaluator{
+		player: ee.player,
+		game:   ee.game,
+		extra:  extra,
+		params: ee.params,
+	}
+}
+
+func (ee *ExpressionEvaluator) Evaluate(expression string) (float64, error) {
+	expr, err := compileExpression(expression)
 	if err != nil {
-		return 0, fmt.Errorf("invalid expression: %w", err)
+		return 0, err
 	}
 
-	params := ee.getParameters(ee.player, ee.game)
-	result, err := expr.Evaluate(params)
+	if ee.params == nil {
+		ee.params = ee.getParameters(ee.player, ee.game)
+	}
+	result, err := expr.Eval(expressionParameters{player: ee.player, extra: ee.extra, values: ee.params})
 
 	if err != nil {
 		return 0, fmt.Errorf("error evaluating expression: %w", err)
@@ -64,17 +110,52 @@ func (ee *ExpressionEvaluator) Evaluate(expression string) (float64, error) {
 	}
 }
 
+// compileExpression разбирает выражение или берет его из кэша
+func compileExpression(expression string) (*govaluate.EvaluableExpression, error) {
+	compiledExpressions.RLock()
+	expr, ok := compiledExpressions.m[expression]
+	compiledExpressions.RUnlock()
+	if ok {
+		return expr, nil
+	}
+
+	prsExpr, err := (&ExpressionEvaluator{}).preprocessExpression(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+	prsExpr = havePattern.ReplaceAllString(prsExpr, "[$1:$2]")
+	expr, err = govaluate.NewEvaluableExpressionWithFunctions(prsExpr, expressionFunctions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+
+	compiledExpressions.Lock()
+	if len(compiledExpressions.m) >= maxCompiledExpressions {
+		compiledExpressions.m = make(map[string]*govaluate.EvaluableExpression)
+	}
+	compiledExpressions.m[expression] = expr
+	compiledExpressions.Unlock()
+	return expr, nil
+}
+
+// getParameters снимает параметры выражений с игрока. Выражения вычисляются в float64,
+// поэтому значения больше ~1.8e308 в обычных параметрах насыщаются до +Inf. Для поздней
+// игры у каждой величины-bignum есть точный десятичный логарифм: [gold:log], [gold:max_log],
+// [gold:ps_log], [gold:earned_log] и т.д.
 func (ee *ExpressionEvaluator) getParameters(player *Player, game *Game) map[string]interface{} {
 	params := make(map[string]interface{})
 
 	for name, item := range player.State.Items {
-		params[name] = item.Amount
-		params[name+":max"] = player.State.ResourceMaxes[name]
-		params[name+":earned"] = player.State.ResourceEarned[name]
-		params[name+":ps"] = player.State.RPS[name]
+		params[name] = item.Amount.Float64()
+		params[name+":log"] = item.Amount.Log10()
+		params[name+":max"] = player.State.ResourceMaxes[name].Float64()
+		params[name+":max_log"] = player.State.ResourceMaxes[name].Log10()
+		params[name+":ps"] = player.State.RPS[name].Float64()
+		params[name+":ps_log"] = player.State.RPS[name].Log10()
 	}
 
 	params["ItemsLeft"] = 100 - float64(len(player.State.Inventory))
+	player.statisticsParameters(params)
 
 	return params
 }
@@ -152,30 +233,14 @@ func (ee *ExpressionEvaluator) and(args ...interface{}) (interface{}, error) {
 	return true, nil
 }
 
-func (ee *ExpressionEvaluator) have(player *Player) govaluate.ExpressionFunction {
-	return func(args ...interface{}) (interface{}, error) {
-		if len(args) != 1 {
-			return nil, fmt.Errorf("have function expects 1 argument")
-		}
-		key, ok := args[0].(string)
-		if !ok {
-			return nil, fmt.Errorf("have function expects a string argument")
-		}
-		return boolToFloat(player.Has(key)), nil
-	}
+// have и no подставляются параметрами на этапе разбора (см. havePattern),
+// сюда попадают только вызовы с нелитеральным аргументом
+func (ee *ExpressionEvaluator) have(args ...interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("have function expects a quoted item id")
 }
 
-func (ee *ExpressionEvaluator) no(player *Player) govaluate.ExpressionFunction {
-	return func(args ...interface{}) (interface{}, error) {
-		if len(args) != 1 {
-			return nil, fmt.Errorf("no function expects 1 argument")
-		}
-		key, ok := args[0].(string)
-		if !ok {
-			return nil, fmt.Errorf("no function expects a string argument")
-		}
-		return boolToFloat(!player.Has(key)), nil
-	}
+func (ee *ExpressionEvaluator) no(args ...interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("no function expects a quoted item id")
 }
 
 func (ee *ExpressionEvaluator) random(args ...interface{}) (interface{}, error) {
@@ -258,6 +323,30 @@ func (ee *ExpressionEvaluator) pow(args ...interface{}) (interface{}, error) {
 	return math.Pow(args[0].(float64), args[1].(float64)), nil
 }
 
+// expressionParameters отдает дополнительные и снятые с игрока параметры, а have:<id> и no:<id>
+// вычисляет по запросу: так они работают и для предметов, которых еще нет в состоянии игрока
+type expressionParameters struct {
+	player *Player
+	extra  map[string]interface{}
+	values map[string]interface{}
+}
+
+func (p expressionParameters) Get(name string) (interface{}, error) {
+	if value, ok := p.extra[name]; ok {
+		return value, nil
+	}
+	if value, ok := p.values[name]; ok {
+		return value, nil
+	}
+	if key, ok := strings.CutPrefix(name, "have:"); ok {
+		return boolToFloat(p.player.Has(key)), nil
+	}
+	if key, ok := strings.CutPrefix(name, "no:"); ok {
+		return boolToFloat(!p.player.Has(key)), nil
+	}
+	return nil, fmt.Errorf("no parameter '%s' found", name)
+}
+
 func boolToFloat(b bool) float64 {
 	if b {
 		return 1
@@ -266,7 +355,10 @@ func boolToFloat(b bool) float64 {
 }
 
 func (p *Player) Has(key string) bool {
-	if amount, ok := p.State.Resources[key]; ok && amount > 0 {
+	if item, ok := p.State.Items[key]; ok && item.Amount.Sign() > 0 {
+		return true
+	}
+	if amount, ok := p.State.Resources[key]; ok && amount.Sign() > 0 {
 		return true
 	}
 	if amount, ok := p.State.Buildings[key]; ok && amount > 0 {
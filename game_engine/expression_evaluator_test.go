@@ -0,0 +1,113 @@
+package game_engine
+
+import (
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/config"
+)
+
+// newTestGame создает игру на конфигурации Gold Rush и нового игрока
+func newTestGame(t *testing.T) (*Game, *Player) {
+	t.Helper()
+	cfg, err := config.LoadConfig("config/gold_rush_config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, err := NewGame(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game, NewPlayer("player", game.ContentSystem)
+}
+
+func TestHaveAndNo(t *testing.T) {
+	_, player := newTestGame(t)
+	// Предмет, которого нет в состоянии игрока, считается отсутствующим, а не ошибкой
+	delete(player.State.Items, "gold_coin")
+	player.State.Achievements["first_gold"] = true
+
+	tests := map[string]float64{
+		"have('pan')":                     1,
+		"no('pan')":                       0,
+		"have('gold_coin')":               0,
+		`no("gold_coin")`:                 1,
+		"have('first_gold')":              1,
+		"have( 'pan' ) + no('gold_coin')": 2,
+		"if (have('pan') == 1) 5":         5,
+		"if (have('gold_coin') == 1) 5":   0,
+	}
+	for expression, want := range tests {
+		got, err := NewExpressionEvaluator(player).Evaluate(expression)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s = %v, want %v", expression, got, want)
+		}
+	}
+}
+
+func TestHaveSharesCompiledExpression(t *testing.T) {
+	game, owner := newTestGame(t)
+	other := NewPlayer("other", game.ContentSystem)
+	delete(other.State.Items, "pan")
+
+	const expression = "have('pan')"
+	if got, _ := NewExpressionEvaluator(owner).Evaluate(expression); got != 1 {
+		t.Errorf("owner: have('pan') = %v, want 1", got)
+	}
+	if got, _ := NewExpressionEvaluator(other).Evaluate(expression); got != 0 {
+		t.Errorf("other: have('pan') = %v, want 0", got)
+	}
+}
+
+func TestHaveRequiresQuotedID(t *testing.T) {
+	_, player := newTestGame(t)
+	if _, err := NewExpressionEvaluator(player).Evaluate("have(pan)"); err == nil {
+		t.Error("expected an error for an unquoted item id")
+	}
+	if _, err := NewExpressionEvaluator(player).Evaluate("missing + 1"); err == nil {
+		t.Error("expected an error for an unknown parameter")
+	}
+}
+
+func TestWithParamsSharesSnapshot(t *testing.T) {
+	_, player := newTestGame(t)
+	evaluator := NewExpressionEvaluator(player)
+	first := evaluator.WithParams(map[string]interface{}{"owned": 1.0})
+	second := evaluator.WithParams(map[string]interface{}{"owned": 2.0})
+
+	if got, _ := first.Evaluate("owned * 10"); got != 10 {
+		t.Errorf("first: owned * 10 = %v, want 10", got)
+	}
+	if got, _ := second.Evaluate("owned * 10"); got != 20 {
+		t.Errorf("second: owned * 10 = %v, want 20", got)
+	}
+	if _, err := evaluator.Evaluate("owned"); err == nil {
+		t.Error("extra parameters must not leak into the source evaluator")
+	}
+	if got, _ := second.Evaluate("pan"); got != 1 {
+		t.Errorf("pan = %v, want 1 from the shared snapshot", got)
+	}
+}
+
+func TestStatisticsParametersBeforeFirstPrestige(t *testing.T) {
+	_, player := newTestGame(t)
+	evaluator := NewExpressionEvaluator(player)
+	got, err := evaluator.Evaluate("[stat:fastest_prestige] < 600")
+	if err != nil {
+		t.Fatalf("fastest run must be defined before the first prestige: %v", err)
+	}
+	if got != 0 {
+		t.Error("a layer that was never prestiged must not count as a fast run")
+	}
+
+	player.recordPrestige("prestige")
+	if got, _ := NewExpressionEvaluator(player).Evaluate("[stat:fastest_prestige] < 600"); got != 1 {
+		t.Error("a prestige right after the start is a fast run")
+	}
+	if got, _ := NewExpressionEvaluator(player).Evaluate("[stat:prestiges]"); got != 1 {
+		t.Errorf("stat:prestiges = %v, want 1", got)
+	}
+}
@@ -0,0 +1,204 @@
+package formatter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// Notation - способ записи больших чисел
+type Notation string
+
+const (
+	// Suffix - короткие суффиксы: 1.5K, 20M, 3B, 4T, 5aa, 6ab...
+	Suffix Notation = "suffix"
+	// Scientific - научная запись: 1.5e12
+	Scientific Notation = "scientific"
+	// Engineering - инженерная запись с порядком, кратным трем: 15e12
+	Engineering Notation = "engineering"
+	// Full - все цифры числа с разделителями разрядов: 1,500,000
+	Full Notation = "full"
+)
+
+// Locale задает разделители целой и дробной части и разрядов
+type Locale struct {
+	DecimalSeparator string
+	GroupSeparator   string
+}
+
+// Locales - поддерживаемые локали форматирования
+var Locales = map[string]Locale{
+	"en": {DecimalSeparator: ".", GroupSeparator: ","},
+	"ru": {DecimalSeparator: ",", GroupSeparator: " "},
+	"de": {DecimalSeparator: ",", GroupSeparator: "."},
+	"fr": {DecimalSeparator: ",", GroupSeparator: " "},
+}
+
+// Options - настройки форматирования, которые игрок может сохранить в своем состоянии
+type Options struct {
+	Notation  Notation `json:"notation"`
+	Precision int      `json:"precision"`
+	Locale    string   `json:"locale"`
+}
+
+// maxFullExponent - порядок, начиная с которого полная запись заменяется научной
+const maxFullExponent = 308
+
+// shortSuffixes - суффиксы для первых групп разрядов, дальше используются aa, ab, ... zz
+var shortSuffixes = []string{"", "K", "M", "B", "T"}
+
+// DefaultOptions возвращает настройки форматирования по умолчанию
+func DefaultOptions() Options {
+	return Options{Notation: Suffix, Precision: 2, Locale: "en"}
+}
+
+// Validate проверяет, что нотация и локаль поддерживаются
+func (o Options) Validate() error {
+	switch o.Notation {
+	case Suffix, Scientific, Engineering, Full:
+	default:
+		return fmt.Errorf("unknown notation: %s", o.Notation)
+	}
+	if _, ok := Locales[o.Locale]; !ok {
+		return fmt.Errorf("unknown locale: %s", o.Locale)
+	}
+	if o.Precision < 0 {
+		return fmt.Errorf("precision must not be negative: %d", o.Precision)
+	}
+	return nil
+}
+
+// Format форматирует число согласно настройкам. Пустые настройки заменяются настройками по умолчанию
+func Format(n bignum.Number, opts Options) string {
+	if opts.Notation == "" {
+		opts = DefaultOptions()
+	}
+	locale, ok := Locales[opts.Locale]
+	if !ok {
+		locale = Locales["en"]
+	}
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n = n.Abs()
+	}
+
+	if opts.Notation != Full && n.Lt(bignum.FromInt(1000)) && roundTo(n.Float64(), opts.Precision) < 1000 {
+		return sign + formatDecimal(n.Float64(), opts.Precision, locale)
+	}
+
+	switch opts.Notation {
+	case Scientific:
+		return sign + formatScientific(n, opts.Precision, locale, 1)
+	case Engineering:
+		return sign + formatScientific(n, opts.Precision, locale, 3)
+	case Full:
+		return sign + formatFull(n, opts.Precision, locale)
+	default:
+		return sign + formatSuffix(n, opts.Precision, locale)
+	}
+}
+
+func formatSuffix(n bignum.Number, precision int, locale Locale) string {
+	mantissa, exponent := scale(n, precision, 3)
+	group := int(exponent / 3)
+	suffix, ok := suffixFor(group)
+	if !ok {
+		return formatScientific(n, precision, locale, 1)
+	}
+	return formatDecimal(mantissa, precision, locale) + suffix
+}
+
+func suffixFor(group int) (string, bool) {
+	if group < len(shortSuffixes) {
+		return shortSuffixes[group], true
+	}
+	index := group - len(shortSuffixes)
+	if index >= 26*26 {
+		return "", false
+	}
+	return string([]byte{byte('a' + index/26), byte('a' + index%26)}), true
+}
+
+func formatScientific(n bignum.Number, precision int, locale Locale, step int64) string {
+	mantissa, exponent := scale(n, precision, step)
+	return formatDecimal(mantissa, precision, locale) + "e" + strconv.FormatInt(exponent, 10)
+}
+
+func formatFull(n bignum.Number, precision int, locale Locale) string {
+	if n.Exponent() < 15 {
+		return formatDecimal(n.Float64(), precision, locale)
+	}
+	if n.Exponent() > maxFullExponent {
+		return formatScientific(n, precision, locale, 1)
+	}
+
+	digits := strings.Replace(strconv.FormatFloat(n.Mantissa(), 'f', -1, 64), ".", "", 1)
+	if zeros := int(n.Exponent()) + 1 - len(digits); zeros > 0 {
+		digits += strings.Repeat("0", zeros)
+	}
+	return groupDigits(digits, locale.GroupSeparator)
+}
+
+// scale возвращает мантиссу и порядок, кратный step, с учетом округления до precision знаков
+func scale(n bignum.Number, precision int, step int64) (float64, int64) {
+	exponent := n.Exponent()
+	shift := exponent - floorDiv(exponent, step)*step
+	mantissa := roundTo(n.Mantissa()*math.Pow10(int(shift)), precision)
+	exponent -= shift
+
+	limit := math.Pow10(int(step))
+	if mantissa >= limit {
+		mantissa /= limit
+		exponent += step
+	}
+	return mantissa, exponent
+}
+
+func formatDecimal(value float64, precision int, locale Locale) string {
+	formatted := strconv.FormatFloat(roundTo(value, precision), 'f', precision, 64)
+	intPart, fracPart, _ := strings.Cut(formatted, ".")
+	fracPart = strings.TrimRight(fracPart, "0")
+
+	result := groupDigits(intPart, locale.GroupSeparator)
+	if fracPart != "" {
+		result += locale.DecimalSeparator + fracPart
+	}
+	return result
+}
+
+func groupDigits(digits, separator string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+func roundTo(value float64, precision int) float64 {
+	factor := math.Pow10(precision)
+	return math.Round(value*factor) / factor
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+func TestFormat(t *testing.T) {
+	en := Options{Notation: Suffix, Precision: 2, Locale: "en"}
+	tests := []struct {
+		name  string
+		value string
+		opts  Options
+		want  string
+	}{
+		{"zero", "0", en, "0"},
+		{"below thousand", "999.99", en, "999.99"},
+		{"trailing zeros trimmed", "12.5", en, "12.5"},
+		{"thousand", "1000", en, "1K"},
+		{"rounds up to next group", "999.995", en, "1K"},
+		{"rounds up inside a group", "999995", en, "1M"},
+		{"last short suffix", "1.5e12", en, "1.5T"},
+		{"first letter suffix", "1e15", en, "1aa"},
+		{"second letter suffix", "2.25e18", en, "2.25ab"},
+		{"last letter suffix", "1e2040", en, "1zz"},
+		{"beyond letter suffixes", "1e2043", en, "1e2043"},
+		{"negative suffix", "-1234.5", en, "-1.23K"},
+		{"negative small", "-0.5", en, "-0.5"},
+		{"empty options use defaults", "1500", Options{}, "1.5K"},
+		{"scientific", "123456", Options{Notation: Scientific, Precision: 2, Locale: "en"}, "1.23e5"},
+		{"scientific rounds up", "9.999e99", Options{Notation: Scientific, Precision: 2, Locale: "en"}, "1e100"},
+		{"scientific beyond float64", "1.5e400", Options{Notation: Scientific, Precision: 1, Locale: "en"}, "1.5e400"},
+		{"engineering", "123456", Options{Notation: Engineering, Precision: 2, Locale: "en"}, "123.46e3"},
+		{"engineering rounds up", "999999", Options{Notation: Engineering, Precision: 2, Locale: "en"}, "1e6"},
+		{"full", "1234567.891", Options{Notation: Full, Precision: 2, Locale: "en"}, "1,234,567.89"},
+		{"full large", "1.5e18", Options{Notation: Full, Precision: 0, Locale: "en"}, "1,500,000,000,000,000,000"},
+		{"full negative", "-1234", Options{Notation: Full, Precision: 0, Locale: "en"}, "-1,234"},
+		{"full beyond float64", "2e400", Options{Notation: Full, Precision: 1, Locale: "en"}, "2e400"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(bignum.MustParse(tt.value), tt.opts); got != tt.want {
+				t.Errorf("Format(%s) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLocales(t *testing.T) {
+	value := bignum.MustParse("1234567.5")
+	tests := []struct {
+		locale string
+		full   string
+		suffix string
+	}{
+		{"en", "1,234,567.5", "1.23M"},
+		{"ru", "1 234 567,5", "1,23M"},
+		{"de", "1.234.567,5", "1,23M"},
+		{"fr", "1 234 567,5", "1,23M"},
+		{"xx", "1,234,567.5", "1.23M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := Format(value, Options{Notation: Full, Precision: 2, Locale: tt.locale}); got != tt.full {
+				t.Errorf("full = %q, want %q", got, tt.full)
+			}
+			if got := Format(value, Options{Notation: Suffix, Precision: 2, Locale: tt.locale}); got != tt.suffix {
+				t.Errorf("suffix = %q, want %q", got, tt.suffix)
+			}
+		})
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	if err := DefaultOptions().Validate(); err != nil {
+		t.Errorf("default options are invalid: %v", err)
+	}
+	for name, opts := range map[string]Options{
+		"unknown notation":   {Notation: "roman", Locale: "en"},
+		"unknown locale":     {Notation: Suffix, Locale: "xx"},
+		"negative precision": {Notation: Suffix, Locale: "en", Precision: -1},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
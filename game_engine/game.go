@@ -2,8 +2,9 @@ package game_engine
 
 import (
 	"fmt"
-	"github.com/ralist/game_engine/game_engine/config"
 	"log"
+
+	"github.com/ralist/game_engine/game_engine/config"
 )
 
 type UIInterface interface {
@@ -28,17 +29,25 @@ type GameState struct {
 }
 
 type Game struct {
-	CommandSystem   CommandSystemInterface
-	EventSystem     *EventSystem
-	expressionCache *Cache
-	ContentSystem   *ContentSystem
-	PluginSystem    *PluginSystem
+	Settings      config.Settings
+	CommandSystem CommandSystemInterface
+	EventSystem   *EventSystem
+	ContentSystem *ContentSystem
+	PluginSystem  *PluginSystem
+	Clock         Clock
+	tickHandlers  []TickHandler
 }
 
+// AchievementLevel - уровень многоуровневого достижения. Если Condition не задано,
+// уровень считается достигнутым, когда выражение прогресса достижения не меньше Target.
+// Rewards - краткая запись множителей производства ресурсов, Effects - любые эффекты,
+// которые выдаются навсегда так же, как эффекты обычных достижений
 type AchievementLevel struct {
 	Level     int                `yaml:"level"`
 	Condition string             `yaml:"condition"`
+	Target    float64            `yaml:"target"`
 	Rewards   map[string]float64 `yaml:"rewards"`
+	Effects   []Effect           `yaml:"effects"`
 }
 
 func NewGame(cfg *config.GameConfig) (*Game, error) {
@@ -46,12 +55,23 @@ func NewGame(cfg *config.GameConfig) (*Game, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create content system: %w", err)
 	}
-	return &Game{
-		EventSystem:     NewEventSystem(),
-		expressionCache: NewCache(),
-		ContentSystem:   content,
-		PluginSystem:    NewPluginSystem(),
-	}, nil
+	game := &Game{
+		Settings:      cfg.Settings,
+		EventSystem:   NewEventSystem(),
+		ContentSystem: content,
+		PluginSystem:  NewPluginSystem(),
+		Clock:         realClock{},
+	}
+	game.RegisterTickHandler(game.statsTick)
+	game.RegisterTickHandler(game.milestoneTick)
+	game.RegisterTickHandler(game.achievementTick)
+	game.RegisterTickHandler(game.shinyTick)
+	game.RegisterTickHandler(game.challengeTick)
+	game.RegisterTickHandler(game.eventTick)
+	game.RegisterTickHandler(game.automatorTick)
+	game.RegisterTickHandler(game.researchTick)
+	content.SetClock(game.Clock)
+	return game, nil
 }
 
 func evaluateExpression(player *Player, expression string) (float64, error) {
@@ -68,111 +88,12 @@ func (g *Game) evaluateCondition(player *Player, condition string) bool {
 	return result > 0
 }
 
-func (g *Game) updatePlayer(player *Player) {
-	for id, amount := range player.State.RPS {
-		player.State.Items[id].Amount += amount
-	}
-}
-
 func (g *Game) Buy(player *Player, itemID string) error {
-	item := player.State.Items[itemID]
-	cost := g.calculateCost(item.Cost, player.GetItemAmount(itemID))
-
-	if player.CanAfford(cost) {
-		player.SpendResources(cost)
-		item.Amount++
-		log.Printf("Player %s bought item: %s (now have %d)", player.ID, item.Name, player.GetItemAmount(itemID))
-		player.AddLog(fmt.Sprintf("Bought item: %s (now have %d)", item.Name, player.GetItemAmount(itemID)))
-
-		player.RecalculateState()
-
-		g.EventSystem.Emit("BuildingBought", map[string]interface{}{
-			"PlayerID": player.ID,
-			"ItemID":   item.ID,
-			"Amount":   player.GetItemAmount(itemID),
-		})
-		return nil
-	}
-
-	return fmt.Errorf("cannot afford item: %s", itemID)
+	_, err := g.BuyBulk(player, itemID, PurchaseRequest{Mode: PurchaseCount, Count: 1})
+	return err
 }
 
 func (g *Game) Sell(player *Player, itemID string) error {
-	item := player.GetItem(itemID)
-	if item == nil || item.Amount <= 0 {
-		return fmt.Errorf("item not found: %s", itemID)
-	}
-
-	g.EventSystem.Emit("BuildingSold", map[string]interface{}{
-		"PlayerID": player.ID,
-		"ItemID":   itemID,
-		"Amount":   item.Amount,
-	})
-
-	return nil
-}
-
-func (g *Game) calculateCost(baseCost map[string]float64, owned int) map[string]float64 {
-	cost := make(map[string]float64)
-	for resource, amount := range baseCost {
-		result := amount * float64(owned+1)
-		cost[resource] = result
-	}
-	return cost
-}
-
-func (g *Game) calculateSellPrice(baseCost map[string]float64) map[string]float64 {
-	sellPrice := make(map[string]float64)
-	for resource, amount := range baseCost {
-		sellPrice[resource] = amount * 0.5
-	}
-	return sellPrice
-}
-
-func (g *Game) PerformPrestige(player *Player) error {
-	prestigeItem, err := g.ContentSystem.GetContent("prestige", "prestige")
-	if err != nil {
-		return fmt.Errorf("error getting prestige content: %w", err)
-	}
-
-	if !player.CanAfford(prestigeItem.Cost) {
-		return fmt.Errorf("cannot afford prestige cost")
-	}
-
-	player.SpendResources(prestigeItem.Cost)
-	player.ResetProgress()
-
-	for _, effect := range prestigeItem.Effects {
-		switch effect.Type {
-		case "multiply":
-			currentAmount := player.State.Resources[effect.Target]
-			player.State.Resources[effect.Target] = uint64(float64(currentAmount) * effect.Value)
-		case "reset":
-			if effect.Target == "all" {
-				for resource := range player.State.Resources {
-					resourceItem, err := g.ContentSystem.GetContent("resources", resource)
-					if err != nil {
-						log.Printf("Error getting resource %s: %v", resource, err)
-						continue
-					}
-					player.State.Resources[resource] = uint64(resourceItem.Initial)
-				}
-			} else {
-				resourceItem, err := g.ContentSystem.GetContent("resources", effect.Target)
-				if err != nil {
-					log.Printf("Error getting resource %s: %v", effect.Target, err)
-				} else {
-					player.State.Resources[effect.Target] = uint64(resourceItem.Initial)
-				}
-			}
-		}
-	}
-
-	player.AddLog(fmt.Sprintf("Performed prestige: %s", prestigeItem.Name))
-	g.EventSystem.Emit("Prestige", map[string]interface{}{
-		"PlayerID":      player.ID,
-		"PrestigeLevel": player.State.Prestige,
-	})
-
-	return nil
+	_, err := g.SellBulk(player, itemID, 1)
+	return err
 }
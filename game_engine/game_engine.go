@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ralist/game_engine/game_engine/bignum"
 	"github.com/ralist/game_engine/game_engine/config"
 )
 
@@ -31,7 +32,7 @@ func NewGameEngine(fileName string, db DatabaseInterface) (*GameEngine, error) {
 }
 
 func (ge *GameEngine) Run() {
-	t := time.NewTicker(1 * time.Second)
+	t := time.NewTicker(ge.Game.TickRate())
 	defer t.Stop()
 	for {
 		select {
@@ -62,6 +63,7 @@ func (ge *GameEngine) updatePlayers() error {
 				errCh <- fmt.Errorf("error unmarshaling player data: %w", err)
 				return
 			}
+			player.attach(ge.Game.ContentSystem)
 			ge.updatePlayer(&player)
 			if err := ge.savePlayer(&player); err != nil {
 				errCh <- fmt.Errorf("error saving player: %w", err)
@@ -82,11 +84,12 @@ func (ge *GameEngine) updatePlayers() error {
 }
 
 func (ge *GameEngine) updatePlayer(player *Player) {
-	ge.Game.updatePlayer(player)
+	ge.Game.Tick(player, ge.Game.Clock.Now())
 }
 
 func (ge *GameEngine) CreatePlayer(playerID string) (*Player, error) {
 	player := NewPlayer(playerID, ge.Game.ContentSystem)
+	player.State.LastSaveTime = ge.Game.Clock.Now()
 	if err := ge.savePlayer(player); err != nil {
 		return nil, fmt.Errorf("error creating player: %w", err)
 	}
@@ -94,7 +97,7 @@ func (ge *GameEngine) CreatePlayer(playerID string) (*Player, error) {
 }
 
 func (ge *GameEngine) GetPlayer(playerID string) (*Player, error) {
-	return ge.loadPlayer(playerID)
+	return ge.readPlayer(playerID)
 }
 
 func (ge *GameEngine) BuyBuilding(playerID, buildingName string) error {
@@ -103,29 +106,283 @@ func (ge *GameEngine) BuyBuilding(playerID, buildingName string) error {
 		return fmt.Errorf("error loading player: %w", err)
 	}
 
-	ge.Game.Buy(player, buildingName)
+	if err := ge.Game.Buy(player, buildingName); err != nil {
+		return err
+	}
 	if err := ge.savePlayer(player); err != nil {
 		return fmt.Errorf("error saving player after buying building: %w", err)
 	}
 	return nil
 }
 
-func (ge *GameEngine) GetPlayerResources(playerID string) (map[string]uint64, error) {
+// BuyItems покупает несколько единиц предмета (N, максимум или до следующего порога)
+func (ge *GameEngine) BuyItems(playerID, itemID string, req PurchaseRequest) (PurchaseQuote, error) {
 	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return PurchaseQuote{}, fmt.Errorf("error loading player: %w", err)
+	}
+
+	quote, err := ge.Game.BuyBulk(player, itemID, req)
+	if err != nil {
+		return quote, err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return quote, fmt.Errorf("error saving player after buying items: %w", err)
+	}
+	return quote, nil
+}
+
+// QuotePurchase рассчитывает покупку без изменения игрока (для кнопок x10 / x100 / max)
+func (ge *GameEngine) QuotePurchase(playerID, itemID string, req PurchaseRequest) (PurchaseQuote, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return PurchaseQuote{}, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.QuoteBuy(player, itemID, req)
+}
+
+func (ge *GameEngine) GetPlayerResources(playerID string) (map[string]bignum.Number, error) {
+	player, err := ge.readPlayer(playerID)
 	if err != nil {
 		return nil, fmt.Errorf("error loading player resources: %w", err)
 	}
-	return player.State.Resources, nil
+	return player.GetResources(), nil
 }
 
 func (ge *GameEngine) GetPlayerBuildings(playerID string) (map[string]int, error) {
-	player, err := ge.loadPlayer(playerID)
+	player, err := ge.readPlayer(playerID)
 	if err != nil {
 		return nil, fmt.Errorf("error loading player buildings: %w", err)
 	}
 	return player.State.Buildings, nil
 }
 
+// ClaimShiny забирает активный "блестящий" объект игрока
+func (ge *GameEngine) ClaimShiny(playerID, shinyID string) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	if err := ge.Game.ClaimShiny(player, shinyID); err != nil {
+		return err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after claiming shiny: %w", err)
+	}
+	return nil
+}
+
+// Prestige выполняет престиж указанного слоя и сохраняет игрока
+func (ge *GameEngine) Prestige(playerID, layerID string) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	if err := ge.Game.PerformPrestige(player, layerID); err != nil {
+		return err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after prestige: %w", err)
+	}
+	return nil
+}
+
+// QuotePrestige возвращает награду за престиж слоя без его выполнения
+func (ge *GameEngine) QuotePrestige(playerID, layerID string) (PrestigeQuote, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return PrestigeQuote{}, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.QuotePrestige(player, layerID)
+}
+
+// StartChallenge начинает испытание и сохраняет игрока
+func (ge *GameEngine) StartChallenge(playerID, challengeID string) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	if err := ge.Game.StartChallenge(player, challengeID); err != nil {
+		return err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after starting challenge: %w", err)
+	}
+	return nil
+}
+
+// AbandonChallenge прекращает активное испытание и сохраняет игрока
+func (ge *GameEngine) AbandonChallenge(playerID string) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	if err := ge.Game.AbandonChallenge(player); err != nil {
+		return err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after abandoning challenge: %w", err)
+	}
+	return nil
+}
+
+// GetChallenges возвращает состояние испытаний игрока
+func (ge *GameEngine) GetChallenges(playerID string) ([]ChallengeStatus, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetChallenges(player), nil
+}
+
+// GetEvents возвращает состояние событий для игрока
+func (ge *GameEngine) GetEvents(playerID string) ([]EventStatus, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetEvents(player), nil
+}
+
+// SetAutomatorEnabled включает или выключает автоматизатор игрока и сохраняет его
+func (ge *GameEngine) SetAutomatorEnabled(playerID, automatorID string, enabled bool) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	if err := ge.Game.SetAutomatorEnabled(player, automatorID, enabled); err != nil {
+		return err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after toggling automator: %w", err)
+	}
+	return nil
+}
+
+// SetAutomatorStrategy меняет стратегию автоматизатора игрока и сохраняет его
+func (ge *GameEngine) SetAutomatorStrategy(playerID, automatorID string, strategy AutomatorStrategy, value float64) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	if err := ge.Game.SetAutomatorStrategy(player, automatorID, strategy, value); err != nil {
+		return err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after configuring automator: %w", err)
+	}
+	return nil
+}
+
+// GetAutomators возвращает состояние автоматизаторов игрока
+func (ge *GameEngine) GetAutomators(playerID string) ([]AutomatorStatus, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetAutomators(player), nil
+}
+
+// GetActiveBoosts возвращает действующие ускорения игрока
+func (ge *GameEngine) GetActiveBoosts(playerID string) ([]ActiveBoost, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetActiveBoosts(player), nil
+}
+
+// StartResearch начинает исследование и сохраняет игрока
+func (ge *GameEngine) StartResearch(playerID, researchID string) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	if err := ge.Game.StartResearch(player, researchID); err != nil {
+		return err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after starting research: %w", err)
+	}
+	return nil
+}
+
+// GetResearch возвращает состояние дерева исследований игрока
+func (ge *GameEngine) GetResearch(playerID string) ([]ResearchStatus, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetResearch(player), nil
+}
+
+// GetStorage возвращает заполненность хранилищ ресурсов игрока
+func (ge *GameEngine) GetStorage(playerID string) ([]StorageStatus, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetStorage(player), nil
+}
+
+// GetStatistics возвращает статистику игрока за текущий забег и за все время
+func (ge *GameEngine) GetStatistics(playerID string) (PlayerStats, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return PlayerStats{}, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetStatistics(player), nil
+}
+
+// RecordClick учитывает ручной клик игрока
+func (ge *GameEngine) RecordClick(playerID string) error {
+	player, err := ge.loadPlayer(playerID)
+	if err != nil {
+		return fmt.Errorf("error loading player: %w", err)
+	}
+	ge.Game.RecordClick(player)
+	if err := ge.savePlayer(player); err != nil {
+		return fmt.Errorf("error saving player after click: %w", err)
+	}
+	return nil
+}
+
+// GetUpcomingMilestones возвращает еще не достигнутые вехи предмета
+func (ge *GameEngine) GetUpcomingMilestones(playerID, itemID string) ([]MilestoneStatus, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetUpcomingMilestones(player, itemID)
+}
+
+// GetAvailableItems возвращает видимые игроку покупаемые предметы по категориям
+func (ge *GameEngine) GetAvailableItems(playerID string) (map[string][]ItemAvailability, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetAvailableItems(player), nil
+}
+
+// GetProductionBreakdown возвращает разбивку производства игрока по ресурсам
+func (ge *GameEngine) GetProductionBreakdown(playerID string) (map[string]*RateBreakdown, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetProductionBreakdown(player), nil
+}
+
+// GetAchievementProgress возвращает прогресс игрока по всем достижениям
+func (ge *GameEngine) GetAchievementProgress(playerID string) ([]AchievementProgress, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading player: %w", err)
+	}
+	return ge.Game.GetAllAchievementProgress(player), nil
+}
+
 func (ge *GameEngine) savePlayer(player *Player) error {
 	data, err := json.Marshal(player)
 	if err != nil {
@@ -137,7 +394,38 @@ func (ge *GameEngine) savePlayer(player *Player) error {
 	return nil
 }
 
+// ResumePlayer загружает игрока, начисляет оффлайн-прогресс и сохраняет результат.
+// Возвращаемая сводка предназначена для экрана "пока вас не было".
+func (ge *GameEngine) ResumePlayer(playerID string) (*Player, *OfflineSummary, error) {
+	player, summary, err := ge.loadPlayerWithProgress(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ge.savePlayer(player); err != nil {
+		return nil, nil, fmt.Errorf("error saving player after offline progress: %w", err)
+	}
+	return player, summary, nil
+}
+
+// loadPlayer загружает игрока и начисляет прогресс с момента последнего сохранения.
+// Вызывающий сохраняет игрока после изменения, иначе начисленный прогресс и события теряются
 func (ge *GameEngine) loadPlayer(playerID string) (*Player, error) {
+	player, _, err := ge.loadPlayerWithProgress(playerID)
+	return player, err
+}
+
+func (ge *GameEngine) loadPlayerWithProgress(playerID string) (*Player, *OfflineSummary, error) {
+	player, err := ge.readPlayer(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	summary := ge.Game.ApplyOfflineProgress(player, ge.Game.Clock.Now())
+	return player, summary, nil
+}
+
+// readPlayer загружает сохраненное состояние игрока без начисления прогресса и без событий.
+// Используется запросами только для чтения, результат которых не сохраняется
+func (ge *GameEngine) readPlayer(playerID string) (*Player, error) {
 	data, err := ge.db.LoadPlayer(playerID)
 	if err != nil {
 		return nil, fmt.Errorf("error loading player from database: %w", err)
@@ -147,6 +435,6 @@ func (ge *GameEngine) loadPlayer(playerID string) (*Player, error) {
 	if err := json.Unmarshal(data, &player); err != nil {
 		return nil, fmt.Errorf("error unmarshaling player data: %w", err)
 	}
-
+	player.attach(ge.Game.ContentSystem)
 	return &player, nil
 }
@@ -0,0 +1,89 @@
+package game_engine
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// Пассивные эффекты действуют, пока предмет принадлежит игроку, и складываются по количеству.
+// Обычно ими пользуются мета-улучшения, которые покупаются за валюту престижа и переживают сбросы
+const (
+	// EffectCost умножает стоимость предмета, категории или всего ("all") на Value за каждую единицу
+	EffectCost = "cost"
+	// EffectOfflineCap добавляет Value секунд к лимиту офлайн-прогресса
+	EffectOfflineCap = "offline_cap"
+	// EffectStarting добавляет Value к начальному количеству ресурса Target после сброса
+	EffectStarting = "starting"
+)
+
+// GetMetaUpgrades возвращает мета-улучшения игрока
+func (p *Player) GetMetaUpgrades() map[string]bignum.Number {
+	return p.getItemsByType("meta_upgrades")
+}
+
+// forEachPassiveEffect вызывает fn для каждого пассивного эффекта effectType у предметов игрока
+func (p *Player) forEachPassiveEffect(effectType string, fn func(effect Effect, value, count float64)) {
+	for _, item := range p.State.Items {
+		if grantedCategories[item.Type] || item.Amount.Sign() <= 0 {
+			continue
+		}
+		for _, effect := range item.Effects {
+			if effect.Type != effectType {
+				continue
+			}
+			value, err := effectValue(p, effect)
+			if err != nil {
+				log.Printf("Error evaluating %s effect of %s: %v", effect.Type, item.ID, err)
+				continue
+			}
+			fn(effect, value, item.Amount.Float64())
+		}
+	}
+	for _, milestone := range p.reachedMilestones() {
+		for _, effect := range milestone.Effects {
+			if effect.Type != effectType {
+				continue
+			}
+			value, err := effectValue(p, effect)
+			if err != nil {
+				log.Printf("Error evaluating %s effect of milestone %s: %v", effect.Type, milestone.ID, err)
+				continue
+			}
+			fn(effect, value, 1)
+		}
+	}
+}
+
+// costMultiplier возвращает множитель стоимости предмета от эффектов cost
+func (p *Player) costMultiplier(itemID, itemType string) float64 {
+	multiplier := 1.0
+	p.forEachPassiveEffect(EffectCost, func(effect Effect, value, count float64) {
+		if effect.Target == itemID || effect.Target == itemType || effect.Target == "all" {
+			multiplier *= math.Pow(value, count)
+		}
+	})
+	return multiplier
+}
+
+// offlineCapBonus возвращает прибавку к лимиту офлайн-прогресса
+func (p *Player) offlineCapBonus() time.Duration {
+	seconds := 0.0
+	p.forEachPassiveEffect(EffectOfflineCap, func(effect Effect, value, count float64) {
+		seconds += value * count
+	})
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// startingAmount возвращает количество ресурса после сброса с учетом эффектов starting
+func (p *Player) startingAmount(resource string, initial int) bignum.Number {
+	amount := bignum.FromInt(initial)
+	p.forEachPassiveEffect(EffectStarting, func(effect Effect, value, count float64) {
+		if effect.Target == resource {
+			amount = amount.Add(bignum.FromFloat(value * count))
+		}
+	})
+	return amount
+}
@@ -0,0 +1,237 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// EffectUnlock - эффект вехи, открывающий предмет Target. Предмет, который открывает
+// хотя бы одна веха, нельзя купить, пока одна из них не достигнута
+const EffectUnlock = "unlock"
+
+// Milestone - веха предмета: достигается, когда выполнено выражение Threshold, и действует
+// до сброса, затронувшего предмет (престиж, испытание). Продажа или трата ниже порога веху
+// не снимает. Count задается для вех по количеству и означает порог "<предмет> >= Count"
+type Milestone struct {
+	ID        string   `json:"id"`
+	ItemID    string   `json:"itemId"`
+	Name      string   `json:"name"`
+	Count     int      `json:"count,omitempty"`
+	Threshold string   `json:"threshold"`
+	Effects   []Effect `json:"effects"`
+}
+
+// MilestoneStatus - состояние вехи для игрока. Remaining известно только для вех по количеству
+type MilestoneStatus struct {
+	Milestone
+	Reached   bool `json:"reached"`
+	Remaining int  `json:"remaining"`
+}
+
+// parseMilestones разбирает вехи предмета itemID
+func parseMilestones(itemID string, list []interface{}) ([]Milestone, error) {
+	milestones := make([]Milestone, 0, len(list))
+	for i, entry := range list {
+		data, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid milestone #%d", i+1)
+		}
+
+		milestone := Milestone{ItemID: itemID}
+		milestone.Count = int(toFloat(data["count"]))
+		milestone.Threshold, _ = data["threshold"].(string)
+		switch {
+		case milestone.Threshold == "" && milestone.Count > 0:
+			milestone.Threshold = fmt.Sprintf("%s >= %d", itemID, milestone.Count)
+		case milestone.Threshold == "":
+			return nil, fmt.Errorf("milestone #%d needs a positive count or a threshold", i+1)
+		}
+
+		milestone.ID, _ = data["id"].(string)
+		if milestone.ID == "" {
+			if milestone.Count == 0 {
+				return nil, fmt.Errorf("milestone #%d with a threshold expression needs an id", i+1)
+			}
+			milestone.ID = fmt.Sprintf("%s_%d", itemID, milestone.Count)
+		}
+		milestone.Name, _ = data["name"].(string)
+		if milestone.Name == "" {
+			milestone.Name = milestone.ID
+		}
+
+		effects, _ := data["effects"].([]interface{})
+		milestone.Effects = parseEffects(effects)
+		if len(milestone.Effects) == 0 {
+			return nil, fmt.Errorf("milestone %s has no effects", milestone.ID)
+		}
+		for _, effect := range milestone.Effects {
+			if _, ok := effectStage(effect.Type); !ok && effect.Type != EffectCost && effect.Type != EffectUnlock {
+				return nil, fmt.Errorf("milestone %s: unsupported effect type %s", milestone.ID, effect.Type)
+			}
+		}
+		milestones = append(milestones, milestone)
+	}
+
+	sort.SliceStable(milestones, func(i, j int) bool {
+		return milestones[i].Count < milestones[j].Count
+	})
+	return milestones, nil
+}
+
+// indexMilestones собирает вехи всех предметов по ID и предметы, которые они открывают
+func indexMilestones(items []GameItem, index map[string]GameItem) (map[string]Milestone, map[string][]string, error) {
+	milestones := make(map[string]Milestone)
+	unlocks := make(map[string][]string)
+	for _, item := range items {
+		for _, milestone := range item.Milestones {
+			if other, ok := milestones[milestone.ID]; ok {
+				return nil, nil, fmt.Errorf("milestone %s is defined by both %s and %s", milestone.ID, other.ItemID, item.ID)
+			}
+			milestones[milestone.ID] = milestone
+			for _, effect := range milestone.Effects {
+				if effect.Type != EffectUnlock {
+					continue
+				}
+				if _, ok := index[effect.Target]; !ok {
+					return nil, nil, fmt.Errorf("milestone %s unlocks unknown item %s", milestone.ID, effect.Target)
+				}
+				unlocks[effect.Target] = append(unlocks[effect.Target], milestone.ID)
+			}
+		}
+	}
+	return milestones, unlocks, nil
+}
+
+// GetMilestone возвращает веху по ID
+func (cs *ContentSystem) GetMilestone(id string) (Milestone, bool) {
+	milestone, ok := cs.milestones[id]
+	return milestone, ok
+}
+
+// reachedMilestones возвращает достигнутые игроком вехи в порядке ID
+func (p *Player) reachedMilestones() []Milestone {
+	ids := make([]string, 0, len(p.State.Milestones))
+	for id := range p.State.Milestones {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	reached := make([]Milestone, 0, len(ids))
+	for _, id := range ids {
+		if milestone, ok := p.Config.GetMilestone(id); ok {
+			reached = append(reached, milestone)
+		}
+	}
+	return reached
+}
+
+// milestoneModifiers возвращает модификаторы производства от достигнутых вех
+func (p *Player) milestoneModifiers() []Modifier {
+	modifiers := make([]Modifier, 0)
+	for _, milestone := range p.reachedMilestones() {
+		for _, effect := range milestone.Effects {
+			stage, ok := effectStage(effect.Type)
+			if !ok {
+				continue
+			}
+			value, err := effectValue(p, effect)
+			if err != nil {
+				log.Printf("Error evaluating %s effect of milestone %s: %v", effect.Type, milestone.ID, err)
+				continue
+			}
+			modifiers = append(modifiers, Modifier{
+				Source: "milestone:" + milestone.ID,
+				Target: effect.Target,
+				Stage:  stage,
+				Value:  value,
+			})
+		}
+	}
+	return modifiers
+}
+
+// milestoneUnlocked проверяет, открыт ли предмет вехами. Предметы, которые не открывает
+// ни одна веха, считаются открытыми
+func (p *Player) milestoneUnlocked(itemID string) bool {
+	unlockers := p.Config.unlocks[itemID]
+	if len(unlockers) == 0 {
+		return true
+	}
+	for _, id := range unlockers {
+		if p.State.Milestones[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// resetMilestones забывает вехи предметов, попавших в сброс
+func (p *Player) resetMilestones(scope ResetScope) {
+	for id := range p.State.Milestones {
+		milestone, ok := p.Config.GetMilestone(id)
+		if !ok {
+			delete(p.State.Milestones, id)
+			continue
+		}
+		if item := p.State.Items[milestone.ItemID]; item != nil && scope.Includes(item) {
+			delete(p.State.Milestones, id)
+		}
+	}
+}
+
+// milestoneTick - этап тика, пересчитывающий вехи с порогами от других величин
+func (g *Game) milestoneTick(player *Player, now time.Time, dt time.Duration) {
+	g.checkMilestones(player)
+}
+
+// checkMilestones отмечает вехи, пороги которых выполнены. Достигнутые вехи не снимаются:
+// их забывает только resetMilestones. Возвращает ID впервые достигнутых вех
+func (g *Game) checkMilestones(player *Player) []string {
+	reached := make([]string, 0)
+	evaluator := NewExpressionEvaluator(player)
+	for _, item := range g.ContentSystem.Items {
+		for _, milestone := range item.Milestones {
+			if player.State.Milestones[milestone.ID] || !g.requirementsMet(evaluator, []string{milestone.Threshold}) {
+				continue
+			}
+
+			player.State.Milestones[milestone.ID] = true
+			reached = append(reached, milestone.ID)
+			player.AddLog(fmt.Sprintf("Milestone reached: %s", milestone.Name))
+			g.EventSystem.Emit("MilestoneReached", map[string]interface{}{
+				"PlayerID":    player.ID,
+				"ItemID":      milestone.ItemID,
+				"MilestoneID": milestone.ID,
+				"Name":        milestone.Name,
+			})
+		}
+	}
+	if len(reached) > 0 {
+		player.RecalculateState()
+	}
+	return reached
+}
+
+// GetUpcomingMilestones возвращает еще не достигнутые вехи предмета, ближайшие первыми
+func (g *Game) GetUpcomingMilestones(player *Player, itemID string) ([]MilestoneStatus, error) {
+	item, ok := g.ContentSystem.GetItem(itemID)
+	if !ok {
+		return nil, fmt.Errorf("item not found: %s", itemID)
+	}
+
+	owned := player.GetItemCount(itemID)
+	upcoming := make([]MilestoneStatus, 0, len(item.Milestones))
+	for _, milestone := range item.Milestones {
+		if player.State.Milestones[milestone.ID] {
+			continue
+		}
+		status := MilestoneStatus{Milestone: milestone}
+		if milestone.Count > owned {
+			status.Remaining = milestone.Count - owned
+		}
+		upcoming = append(upcoming, status)
+	}
+	return upcoming, nil
+}
@@ -0,0 +1,31 @@
+package game_engine
+
+import (
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+func TestMilestonesStayReachedUntilReset(t *testing.T) {
+	game, player := newTestGame(t)
+	player.State.Items["pan"].Amount = bignum.FromInt(30)
+
+	if reached := game.checkMilestones(player); len(reached) != 1 || reached[0] != "pan_25" {
+		t.Fatalf("reached %v, want [pan_25]", reached)
+	}
+	if reached := game.checkMilestones(player); len(reached) != 0 {
+		t.Errorf("milestones reached twice: %v", reached)
+	}
+
+	if _, err := game.SellBulk(player, "pan", 10); err != nil {
+		t.Fatal(err)
+	}
+	if !player.State.Milestones["pan_25"] {
+		t.Error("selling below the threshold must not revoke the milestone")
+	}
+
+	player.ResetProgress(ResetScope{Resets: []string{"buildings"}})
+	if player.State.Milestones["pan_25"] {
+		t.Error("a reset of the item must forget its milestones")
+	}
+}
@@ -0,0 +1,156 @@
+package game_engine
+
+import (
+	"log"
+	"sort"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// ModifierStage - этап конвейера расчета производства
+type ModifierStage string
+
+const (
+	// StageAdditive прибавляет значение к базовому производству ресурса
+	StageAdditive ModifierStage = "add"
+	// StageMultiplicative умножает производство ресурса
+	StageMultiplicative ModifierStage = "multiply"
+	// StageExponent возводит производство ресурса в степень
+	StageExponent ModifierStage = "exponent"
+)
+
+// stageOrder задает порядок применения этапов: (база + слагаемые) * множители ^ степени
+var stageOrder = map[ModifierStage]int{
+	StageAdditive:       0,
+	StageMultiplicative: 1,
+	StageExponent:       2,
+}
+
+// Modifier - модификатор производства ресурса Target из источника Source
+type Modifier struct {
+	Source string        `json:"source"`
+	Target string        `json:"target"`
+	Stage  ModifierStage `json:"stage"`
+	Value  float64       `json:"value"`
+}
+
+// AddModifier добавляет постоянный модификатор, который переживает сбросы прогресса
+func (p *Player) AddModifier(modifier Modifier) {
+	p.State.Modifiers = append(p.State.Modifiers, modifier)
+	p.RecalculateState()
+}
+
+// grantedCategories - категории, эффекты которых выдаются один раз при получении: достижения,
+// прохождение испытания, престиж, находки и награды событий. Пока такой предмет есть у игрока,
+// его эффекты не действуют как эффекты купленного предмета
+var grantedCategories = map[string]bool{
+	"achievements": true,
+	"challenges":   true,
+	"prestige":     true,
+	"shinies":      true,
+	"events":       true,
+}
+
+// collectModifiers собирает модификаторы от купленных предметов, полученных достижений,
+// постоянных бонусов игрока, достигнутых вех, активного испытания и временных ускорений
+// в порядке применения
+func (p *Player) collectModifiers() []Modifier {
+	modifiers := make([]Modifier, 0, len(p.State.Modifiers))
+	for _, item := range p.State.Items {
+		count := item.Amount
+		if grantedCategories[item.Type] || count.Sign() <= 0 {
+			continue
+		}
+
+		for _, effect := range item.Effects {
+			stage, ok := effectStage(effect.Type)
+			if !ok {
+				continue
+			}
+			value, err := effectValue(p, effect)
+			if err != nil {
+				log.Printf("Error evaluating %s effect of %s: %v", effect.Type, item.ID, err)
+				continue
+			}
+			modifiers = append(modifiers, stackModifier(Modifier{
+				Source: item.ID,
+				Target: effect.Target,
+				Stage:  stage,
+				Value:  value,
+			}, count.Float64()))
+		}
+	}
+	modifiers = append(modifiers, p.State.Modifiers...)
+	modifiers = append(modifiers, p.milestoneModifiers()...)
+	modifiers = append(modifiers, p.challengeModifiers()...)
+	modifiers = append(modifiers, p.boostModifiers()...)
+
+	sort.SliceStable(modifiers, func(i, j int) bool {
+		if modifiers[i].Stage != modifiers[j].Stage {
+			return stageOrder[modifiers[i].Stage] < stageOrder[modifiers[j].Stage]
+		}
+		return modifiers[i].Source < modifiers[j].Source
+	})
+	return modifiers
+}
+
+// applyModifiers применяет модификаторы к базовому производству ресурсов и дописывает
+// каждый шаг в разбивку соответствующего ресурса
+func applyModifiers(breakdowns map[string]*RateBreakdown, modifiers []Modifier) {
+	for _, modifier := range modifiers {
+		breakdown := breakdownFor(breakdowns, modifier.Target)
+		before := breakdown.Final
+		breakdown.Final = modifier.apply(before)
+		breakdown.Modifiers = append(breakdown.Modifiers, AppliedModifier{
+			Modifier: modifier,
+			Before:   before,
+			After:    breakdown.Final,
+		})
+	}
+}
+
+// apply применяет модификатор к значению производства
+func (m Modifier) apply(rate bignum.Number) bignum.Number {
+	switch m.Stage {
+	case StageAdditive:
+		return rate.Add(bignum.FromFloat(m.Value))
+	case StageMultiplicative:
+		return rate.MulFloat(m.Value)
+	case StageExponent:
+		return rate.Pow(m.Value)
+	default:
+		return rate
+	}
+}
+
+// stackModifier учитывает количество единиц источника: слагаемые складываются, множители и степени перемножаются
+func stackModifier(modifier Modifier, count float64) Modifier {
+	if count == 1 {
+		return modifier
+	}
+	switch modifier.Stage {
+	case StageAdditive:
+		modifier.Value *= count
+	default:
+		modifier.Value = bignum.FromFloat(modifier.Value).Pow(count).Float64()
+	}
+	return modifier
+}
+
+// effectStage возвращает этап конвейера для типа эффекта
+func effectStage(effectType string) (ModifierStage, bool) {
+	switch ModifierStage(effectType) {
+	case StageAdditive, StageMultiplicative, StageExponent:
+		return ModifierStage(effectType), true
+	default:
+		return "", false
+	}
+}
+
+// effectValue возвращает значение эффекта: результат выражения, если оно задано, иначе Value
+func effectValue(p *Player, effect Effect) (float64, error) {
+	if effect.Expression == "" {
+		return effect.Value, nil
+	}
+	return evaluateExpression(p, effect.Expression)
+}
@@ -0,0 +1,110 @@
+package game_engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+const (
+	// defaultMaxOfflineTime используется, если в конфигурации не задан max_offline_time
+	defaultMaxOfflineTime = 24 * time.Hour
+	// offlineSegments - на сколько отрезков делится оффлайн-период, чтобы достижения,
+	// полученные по пути, успевали повлиять на оставшуюся часть производства
+	offlineSegments = 100
+)
+
+// OfflineSummary описывает прогресс игрока за время отсутствия ("пока вас не было")
+type OfflineSummary struct {
+	Away         time.Duration            `json:"away"`
+	Credited     time.Duration            `json:"credited"`
+	Earned       map[string]bignum.Number `json:"earned"`
+	Capped       []string                 `json:"capped"`
+	Achievements []string                 `json:"achievements"`
+}
+
+// ApplyOfflineProgress начисляет игроку производство за время с момента последнего сохранения
+func (g *Game) ApplyOfflineProgress(player *Player, now time.Time) *OfflineSummary {
+	summary := &OfflineSummary{
+		Earned:       make(map[string]bignum.Number),
+		Capped:       make([]string, 0),
+		Achievements: make([]string, 0),
+	}
+
+	if player.State.LastSaveTime.IsZero() {
+		player.State.LastSaveTime = now
+		return summary
+	}
+
+	summary.Away = now.Sub(player.State.LastSaveTime)
+	if summary.Away <= 0 {
+		return summary
+	}
+
+	summary.Credited = summary.Away
+	if maxOffline := g.maxOfflineTime(player); summary.Credited > maxOffline {
+		summary.Credited = maxOffline
+	}
+
+	before := make(map[string]bignum.Number)
+	for id, item := range player.State.Items {
+		if item.Type == "resources" {
+			before[id] = item.Amount
+		}
+	}
+
+	achieved := make(map[string]bool, len(player.State.Achievements))
+	for id, ok := range player.State.Achievements {
+		achieved[id] = ok
+	}
+
+	step := summary.Credited / offlineSegments
+	if step < time.Second {
+		step = time.Second
+	}
+	for remaining := summary.Credited; remaining > 0; remaining -= step {
+		dt := step
+		if remaining < dt {
+			dt = remaining
+		}
+		g.advance(player, player.State.LastSaveTime.Add(dt))
+	}
+
+	for _, id := range g.ContentSystem.GetSortedIDs("achievements") {
+		if player.State.Achievements[id] && !achieved[id] {
+			summary.Achievements = append(summary.Achievements, id)
+		}
+	}
+
+	for id, amount := range before {
+		if earned := player.GetItemAmount(id).Sub(amount); earned.Sign() > 0 {
+			summary.Earned[id] = earned
+		}
+		if maxAmount := player.State.ResourceMaxes[id]; maxAmount.Sign() > 0 && player.GetItemAmount(id).Gte(maxAmount) {
+			summary.Capped = append(summary.Capped, id)
+		}
+	}
+
+	player.State.LastSaveTime = now
+	// Короткие паузы между командами догоняются молча, чтобы не засорять журнал игрока
+	if summary.Away <= offlineThreshold {
+		return summary
+	}
+	player.AddLog(fmt.Sprintf("Welcome back! You were away for %s", summary.Away.Round(time.Second)))
+
+	g.EventSystem.Emit("OfflineProgress", map[string]interface{}{
+		"PlayerID": player.ID,
+		"Summary":  summary,
+	})
+
+	return summary
+}
+
+func (g *Game) maxOfflineTime(player *Player) time.Duration {
+	maxOffline := defaultMaxOfflineTime
+	if g.Settings.MaxOfflineTime > 0 {
+		maxOffline = g.Settings.MaxOfflineTime
+	}
+	return maxOffline + player.offlineCapBonus()
+}
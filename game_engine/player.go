@@ -2,52 +2,73 @@ package game_engine
 
 import (
 	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+	"github.com/ralist/game_engine/game_engine/formatter"
 )
 
 type PlayerItem struct {
 	ID             string
 	Type           string
-	Name           string                 `json:"name"`
-	Description    string                 `json:"description"`
-	Cost           map[string]float64     `json:"cost"`
-	RPS            map[string]float64     `json:"resourcePerSecond"`
-	ResourceEarned map[string]uint64      `json:"resourceEarned"`
-	Amount         int                    `json:"amount"`
-	Effects        []Effect               `json:"effects"`
-	Reqs           []string               `json:"reqs"`
-	Properties     map[string]interface{} `json:"properties"`
+	Name           string                   `json:"name"`
+	Description    string                   `json:"description"`
+	Cost           map[string]float64       `json:"cost"`
+	RPS            map[string]float64       `json:"resourcePerSecond"`
+	ResourceEarned map[string]bignum.Number `json:"resourceEarned"`
+	Amount         bignum.Number            `json:"amount"`
+	Effects        []Effect                 `json:"effects"`
+	Reqs           []string                 `json:"reqs"`
+	Properties     map[string]interface{}   `json:"properties"`
 }
 
 // PlayerState представляет текущее состояние игрока
 type PlayerState struct {
-	Resources         map[string]uint64      `json:"resources"`
-	Upgrades          map[string]bool        `json:"upgrades"`
-	Buildings         map[string]int         `json:"buildings"`
-	Achievements      map[string]bool        `json:"achievements"`
-	Shinies           map[string]ShinyState  `json:"shinies"`
-	Prestige          int                    `json:"prestige"`
-	LastSaveTime      time.Time              `json:"lastSaveTime"`
-	Log               []string               `json:"log"`
-	AchievementLevels map[string]int         `json:"achievementLevels"`
-	Items             map[string]*PlayerItem `json:"data"`
-	ResourceMaxes     map[string]uint64      `json:"resourceMaxes"`
-	ResourceEarned    map[string]uint64      `json:"resourceEarned"`
-	RPS               map[string]int         `json:"resourcePerSecond"`
-	Inventory         []string               `json:"inventory"`
+	Resources         map[string]bignum.Number `json:"resources"`
+	Upgrades          map[string]bool          `json:"upgrades"`
+	Buildings         map[string]int           `json:"buildings"`
+	Achievements      map[string]bool          `json:"achievements"`
+	Shinies           map[string]ShinyState    `json:"shinies"`
+	Prestige          int                      `json:"prestige"`
+	PrestigeLayers    map[string]int           `json:"prestigeLayers"`
+	PrestigeAwarded   map[string]bignum.Number `json:"prestigeAwarded"`
+	LastSaveTime      time.Time                `json:"lastSaveTime"`
+	Log               []string                 `json:"log"`
+	AchievementLevels map[string]int           `json:"achievementLevels"`
+	Items             map[string]*PlayerItem   `json:"data"`
+	ResourceMaxes     map[string]bignum.Number `json:"resourceMaxes"`
+	// ResourceEarned - заработок за все время из старых сохранений, при загрузке переносится в Stats
+	ResourceEarned   map[string]bignum.Number     `json:"resourceEarned,omitempty"`
+	RPS              map[string]bignum.Number     `json:"resourcePerSecond"`
+	Inventory        []string                     `json:"inventory"`
+	NumberFormat     formatter.Options            `json:"numberFormat"`
+	Modifiers        []Modifier                   `json:"modifiers"`
+	ActiveChallenge  string                       `json:"activeChallenge,omitempty"`
+	ChallengeStarted time.Time                    `json:"challengeStarted"`
+	Challenges       map[string]ChallengeRecord   `json:"challenges"`
+	Events           map[string]EventState        `json:"events"`
+	Automators       map[string]AutomatorSettings `json:"automators"`
+	Boosts           []ActiveBoost                `json:"boosts"`
+	Research         *ActiveResearch              `json:"research,omitempty"`
+	Stats            PlayerStats                  `json:"stats"`
+	Milestones       map[string]bool              `json:"milestones"`
 }
 
 // ShinyState представляет состояние "блестящего" объекта
 type ShinyState struct {
 	Active    bool      `json:"active"`
 	LastSpawn time.Time `json:"lastSpawn"`
+	NextSpawn time.Time `json:"nextSpawn"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 // Player представляет игрока в игре
 type Player struct {
-	ID                string       `json:"id"`
-	State             *PlayerState `json:"state"`
-	Config            *ContentSystem
+	ID                string         `json:"id"`
+	State             *PlayerState   `json:"state"`
+	Config            *ContentSystem `json:"-"`
 	ResourcePerSecond interface{}
+	// Breakdown - разбивка производства по ресурсам, пересчитывается в RecalculateState
+	Breakdown map[string]*RateBreakdown `json:"-"`
 }
 
 // NewPlayer создает нового игрока с заданным ID и конфигурацией
@@ -55,13 +76,21 @@ func NewPlayer(playerID string, cfg *ContentSystem) *Player {
 	p := &Player{
 		ID: playerID,
 		State: &PlayerState{
+			Stats:             newPlayerStats(),
+			Milestones:        make(map[string]bool),
 			Achievements:      make(map[string]bool),
 			Shinies:           make(map[string]ShinyState),
 			Prestige:          0,
+			PrestigeLayers:    make(map[string]int),
+			PrestigeAwarded:   make(map[string]bignum.Number),
+			Challenges:        make(map[string]ChallengeRecord),
+			Events:            make(map[string]EventState),
+			Automators:        make(map[string]AutomatorSettings),
 			Items:             initItems(cfg),
-			LastSaveTime:      time.Now(),
+			LastSaveTime:      cfg.now(),
 			AchievementLevels: make(map[string]int),
 			Log:               make([]string, 0, 10),
+			NumberFormat:      formatter.DefaultOptions(),
 		},
 		Config: cfg,
 	}
@@ -70,68 +99,154 @@ func NewPlayer(playerID string, cfg *ContentSystem) *Player {
 	return p
 }
 
+// attach связывает загруженного игрока с системой контента и инициализирует пустые поля состояния
+func (p *Player) attach(cfg *ContentSystem) {
+	p.Config = cfg
+	if p.State == nil {
+		p.State = &PlayerState{}
+	}
+	p.State.Stats.init()
+	if p.State.Milestones == nil {
+		p.State.Milestones = make(map[string]bool)
+	}
+	for resource, earned := range p.State.ResourceEarned {
+		p.State.Stats.AllTime.Earned[resource] = p.State.Stats.AllTime.Earned[resource].Add(earned)
+	}
+	p.State.ResourceEarned = nil
+	if p.State.Achievements == nil {
+		p.State.Achievements = make(map[string]bool)
+	}
+	if p.State.Shinies == nil {
+		p.State.Shinies = make(map[string]ShinyState)
+	}
+	if p.State.PrestigeLayers == nil {
+		p.State.PrestigeLayers = make(map[string]int)
+	}
+	if p.State.PrestigeAwarded == nil {
+		p.State.PrestigeAwarded = make(map[string]bignum.Number)
+	}
+	if p.State.Challenges == nil {
+		p.State.Challenges = make(map[string]ChallengeRecord)
+	}
+	if p.State.Events == nil {
+		p.State.Events = make(map[string]EventState)
+	}
+	if p.State.Automators == nil {
+		p.State.Automators = make(map[string]AutomatorSettings)
+	}
+	if p.State.AchievementLevels == nil {
+		p.State.AchievementLevels = make(map[string]int)
+	}
+	if p.State.Items == nil {
+		p.State.Items = initItems(cfg)
+	}
+	p.RecalculateState()
+}
+
 func initItems(cfg *ContentSystem) map[string]*PlayerItem {
 	items := make(map[string]*PlayerItem)
 	for _, item := range cfg.Items {
-		playerItem := &PlayerItem{
-			ID:          item.ID,
-			Type:        item.Type,
-			Name:        item.Name,
-			Description: item.Description,
-			Cost:        item.Cost,
-			Reqs:        item.Reqs,
-			Properties:  item.Properties,
-			Amount:      item.Initial,
-			Effects:     item.Effects,
-		}
-		items[item.ID] = playerItem
+		items[item.ID] = newPlayerItem(item)
 	}
 
 	return items
 }
 
+// newPlayerItem создает предмет игрока с начальным количеством из конфигурации
+func newPlayerItem(item GameItem) *PlayerItem {
+	return &PlayerItem{
+		ID:          item.ID,
+		Type:        item.Type,
+		Name:        item.Name,
+		Description: item.Description,
+		Cost:        item.Cost,
+		Reqs:        item.Reqs,
+		Properties:  item.Properties,
+		Amount:      bignum.FromInt(item.Initial),
+		Effects:     item.Effects,
+	}
+}
+
 func (p *Player) RecalculateState() {
-	rps := map[string]int{}
+	breakdowns := map[string]*RateBreakdown{}
+	evaluator := NewExpressionEvaluator(p)
 	for _, item := range p.State.Items {
-		if item.Amount == 0 {
+		if item.Amount.IsZero() || grantedCategories[item.Type] {
 			continue
 		}
 
 		for _, effect := range item.Effects {
 			if effect.Type == "yield" {
-				value, _ := evaluateExpression(p, effect.Expression)
-				rps[effect.Target] += int(value)
+				value, _ := evaluator.Evaluate(effect.Expression)
+				breakdownFor(breakdowns, effect.Target).addSource(item, bignum.FromFloat(value))
 			}
 		}
 	}
 
+	for _, breakdown := range breakdowns {
+		breakdown.sortSources()
+		breakdown.Final = breakdown.Base
+	}
+	applyModifiers(breakdowns, p.collectModifiers())
+	p.calculateCaps()
+	for _, breakdown := range breakdowns {
+		breakdown.Net = breakdown.Final
+	}
+	p.addConverterFlows(breakdowns)
+
+	rps := make(map[string]bignum.Number, len(breakdowns))
+	for resource, breakdown := range breakdowns {
+		rps[resource] = breakdown.Final
+	}
 	p.State.RPS = rps
+	p.Breakdown = breakdowns
 }
 
-// AddItem добавляет ресурсы игроку
-func (p *Player) AddItem(itemID string, amount float64) {
+// AddItem добавляет ресурсы игроку. Ресурсы, как и при производстве, ограничены хранилищем,
+// а в заработок попадает только поместившаяся часть
+func (p *Player) AddItem(itemID string, amount bignum.Number) {
 	item := p.State.Items[itemID]
-	if item.Type == "buildings" {
-		item.Amount += int(amount)
+	if item == nil {
+		return
+	}
+	if item.Type == "resources" {
+		before := item.Amount
+		item.Amount = p.clampToCap(itemID, before, before.Add(amount))
+		p.earn(itemID, item.Amount.Sub(before))
+	} else if item.Type == "buildings" {
+		item.Amount = item.Amount.Add(amount)
+		p.recordOwned(itemID)
 	} else {
-		if item.Amount > 0 {
+		if item.Amount.Sign() > 0 {
 			return
 		}
 
-		item.Amount += int(amount)
+		item.Amount = item.Amount.Add(amount)
 	}
 }
 
+// earn учитывает полученный ресурс в статистике забега и за все время. Заработок
+// за все время сбросы престижа не затрагивают
+func (p *Player) earn(resource string, amount bignum.Number) {
+	if amount.Sign() <= 0 {
+		return
+	}
+	p.State.Stats.both(func(stats *Statistics) {
+		stats.Earned[resource] = stats.Earned[resource].Add(amount)
+	})
+}
+
 // RemoveItem удаляет ресурсы у игрока
-func (p *Player) RemoveItem(itemID string, amount float64) {
-	p.State.Items[itemID].Amount -= int(amount)
+func (p *Player) RemoveItem(itemID string, amount bignum.Number) {
+	item := p.State.Items[itemID]
+	item.Amount = item.Amount.Sub(amount)
 }
 
 // CanAfford проверяет, может ли игрок позволить себе покупку
-func (p *Player) CanAfford(cost map[string]float64) bool {
+func (p *Player) CanAfford(cost map[string]bignum.Number) bool {
 	for resource, amount := range cost {
 		res := p.State.Items[resource]
-		if res.Amount < int(amount) || amount == 0 {
+		if res == nil || res.Amount.Lt(amount) || amount.IsZero() {
 			return false
 		}
 	}
@@ -140,43 +255,48 @@ func (p *Player) CanAfford(cost map[string]float64) bool {
 }
 
 // SpendResources тратит ресурсы игрока
-func (p *Player) SpendResources(cost map[string]float64) {
+func (p *Player) SpendResources(cost map[string]bignum.Number) {
 	for resource, amount := range cost {
-		p.State.Items[resource].Amount -= int(amount)
+		p.RemoveItem(resource, amount)
+		p.recordSpent(resource, amount)
 	}
 }
 
-func (p *Player) GetResources() map[string]float64 {
-	resources := make(map[string]float64)
-	for id, item := range p.State.Items {
-		if item.Type == "resources" {
-			resources[id] = float64(item.Amount)
-		}
-	}
+func (p *Player) GetResources() map[string]bignum.Number {
+	return p.getItemsByType("resources")
+}
+
+func (p *Player) GetBuildings() map[string]bignum.Number {
+	return p.getItemsByType("buildings")
+}
 
-	return resources
+func (p *Player) GetUpgrades() map[string]bignum.Number {
+	return p.getItemsByType("upgrades")
 }
 
-func (p *Player) GetBuildings() map[string]float64 {
-	resources := make(map[string]float64)
+func (p *Player) getItemsByType(itemType string) map[string]bignum.Number {
+	items := make(map[string]bignum.Number)
 	for id, item := range p.State.Items {
-		if item.Type == "buildings" {
-			resources[id] = float64(item.Amount)
+		if item.Type == itemType {
+			items[id] = item.Amount
 		}
 	}
 
-	return resources
+	return items
 }
 
-func (p *Player) GetUpgrades() map[string]float64 {
-	resources := make(map[string]float64)
-	for id, item := range p.State.Items {
-		if item.Type == "upgrades" {
-			resources[id] = float64(item.Amount)
-		}
-	}
+// FormatNumber форматирует число согласно настройкам игрока
+func (p *Player) FormatNumber(n bignum.Number) string {
+	return formatter.Format(n, p.State.NumberFormat)
+}
 
-	return resources
+// SetNumberFormat сохраняет настройки форматирования чисел игрока
+func (p *Player) SetNumberFormat(opts formatter.Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	p.State.NumberFormat = opts
+	return nil
 }
 
 // AddLog добавляет сообщение в лог игрока
@@ -187,32 +307,50 @@ func (p *Player) AddLog(message string) {
 	}
 }
 
-// ResetProgress сбрасывает прогресс игрока и увеличивает уровень престижа
-func (p *Player) ResetProgress() {
-	p.State.Prestige++
+// ResetProgress сбрасывает предметы, попадающие в scope, к начальным значениям из конфигурации
+func (p *Player) ResetProgress(scope ResetScope) {
+	for id, item := range p.State.Items {
+		if !scope.Includes(item) {
+			continue
+		}
+		item.Amount = bignum.Zero()
+		if content, ok := p.Config.GetItem(id); ok {
+			item.Amount = bignum.FromInt(content.Initial)
+		}
+		if item.Type == "resources" {
+			item.Amount = p.startingAmount(id, item.Amount.Int())
+		}
+	}
+
 	resources := p.Config.GetAllContent("resources")
 	for resource := range p.State.Resources {
-		if resourceItem, ok := resources[resource]; ok {
-			p.State.Resources[resource] = uint64(resourceItem.Initial)
-		} else {
-			p.State.Resources[resource] = 0
+		if !scope.Includes(&PlayerItem{ID: resource, Type: "resources"}) {
+			continue
 		}
+		p.State.Resources[resource] = p.startingAmount(resource, resources[resource].Initial)
 	}
 	for name := range p.State.Buildings {
-		p.State.Buildings[name] = 0
+		if scope.Includes(&PlayerItem{ID: name, Type: "buildings"}) {
+			p.State.Buildings[name] = 0
+		}
 	}
-	p.AddLog("You have prestiged! All your progress has been reset, but you now earn more resources.")
+	p.resetMilestones(scope)
+
+	p.RecalculateState()
 }
 
-// GetItemAmount возвращает количество зданий определенного типа
-func (p *Player) GetItemAmount(itemID string) int {
-	for _, item := range p.State.Items {
-		if item.ID == itemID {
-			return item.Amount
-		}
+// GetItemAmount возвращает количество предмета или ресурса
+func (p *Player) GetItemAmount(itemID string) bignum.Number {
+	if item, ok := p.State.Items[itemID]; ok {
+		return item.Amount
 	}
 
-	return 0
+	return bignum.Zero()
+}
+
+// GetItemCount возвращает количество купленных единиц предмета в виде целого числа
+func (p *Player) GetItemCount(itemID string) int {
+	return p.GetItemAmount(itemID).Int()
 }
 
 func (p *Player) GetItem(itemID string) *PlayerItem {
@@ -0,0 +1,256 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// PrestigeLayer - слой престижа из категории prestige. Formula вычисляет, сколько валюты
+// слоя положено за все время (обычно от заработка [resource:earned]); при престиже выдается
+// разница с уже полученным. Слои с большим Tier сбрасывают также прогресс, счетчики и валюту
+// всех нижних слоев
+type PrestigeLayer struct {
+	ID       string
+	Name     string
+	Tier     int
+	Currency string
+	Formula  string
+	Resets   []string
+	Keeps    []string
+	Reqs     []string
+	Cost     map[string]float64
+	Effects  []Effect
+}
+
+// ResetScope описывает, какие предметы сбрасываются: категории или отдельные ID из Resets,
+// за исключением перечисленных в Keeps
+type ResetScope struct {
+	Resets []string
+	Keeps  []string
+}
+
+// Includes проверяет, попадает ли предмет под сброс. Контентом событий управляет само событие,
+// поэтому сбросы его не затрагивают
+func (s ResetScope) Includes(item *PlayerItem) bool {
+	if _, ok := item.Properties["event"]; ok {
+		return false
+	}
+	for _, keep := range s.Keeps {
+		if keep == item.ID || keep == item.Type {
+			return false
+		}
+	}
+	for _, reset := range s.Resets {
+		if reset == item.ID || reset == item.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// PrestigeQuote - что получит игрок, если выполнит престиж прямо сейчас
+type PrestigeQuote struct {
+	LayerID   string        `json:"layerId"`
+	Currency  string        `json:"currency"`
+	Gain      bignum.Number `json:"gain"`
+	Level     int           `json:"level"`
+	Available bool          `json:"available"`
+	Reason    string        `json:"reason,omitempty"`
+}
+
+// parsePrestigeLayers создает слои престижа из категории prestige
+func parsePrestigeLayers(items map[string]GameItem) ([]PrestigeLayer, error) {
+	layers := make([]PrestigeLayer, 0, len(items))
+	for id, item := range items {
+		layer := PrestigeLayer{
+			ID:      id,
+			Name:    item.Name,
+			Tier:    int(toFloat(item.Properties["tier"])),
+			Reqs:    item.Reqs,
+			Cost:    item.Cost,
+			Effects: item.Effects,
+			Resets:  toStringSlice(item.Properties["resets"]),
+			Keeps:   toStringSlice(item.Properties["keeps"]),
+		}
+		if currency, ok := item.Properties["currency"].(string); ok {
+			layer.Currency = currency
+		}
+		if formula, ok := item.Properties["formula"].(string); ok {
+			layer.Formula = formula
+		}
+		if layer.Currency != "" && layer.Formula == "" {
+			return nil, fmt.Errorf("prestige layer %s has a currency but no formula", id)
+		}
+		layers = append(layers, layer)
+	}
+
+	sort.Slice(layers, func(i, j int) bool {
+		if layers[i].Tier != layers[j].Tier {
+			return layers[i].Tier < layers[j].Tier
+		}
+		return layers[i].ID < layers[j].ID
+	})
+	return layers, nil
+}
+
+// GetPrestigeLayers возвращает слои престижа в порядке возрастания уровня
+func (cs *ContentSystem) GetPrestigeLayers() []PrestigeLayer {
+	return cs.prestigeLayers
+}
+
+// GetPrestigeLayer возвращает слой престижа по ID
+func (cs *ContentSystem) GetPrestigeLayer(id string) (PrestigeLayer, error) {
+	for _, layer := range cs.prestigeLayers {
+		if layer.ID == id {
+			return layer, nil
+		}
+	}
+	return PrestigeLayer{}, fmt.Errorf("prestige layer not found: %s", id)
+}
+
+// scope возвращает область сброса слоя. Валюта этого и более высоких слоев
+// никогда не сбрасывается, валюта нижних слоев сбрасывается всегда
+func (l PrestigeLayer) scope(layers []PrestigeLayer) ResetScope {
+	scope := ResetScope{
+		Resets: append([]string(nil), l.Resets...),
+		Keeps:  append([]string(nil), l.Keeps...),
+	}
+	for _, other := range layers {
+		if other.Currency == "" {
+			continue
+		}
+		if other.Tier < l.Tier {
+			scope.Resets = append(scope.Resets, other.Currency)
+		} else {
+			scope.Keeps = append(scope.Keeps, other.Currency)
+		}
+	}
+	return scope
+}
+
+// QuotePrestige рассчитывает награду за престиж без изменения игрока
+func (g *Game) QuotePrestige(player *Player, layerID string) (PrestigeQuote, error) {
+	layer, err := g.ContentSystem.GetPrestigeLayer(layerID)
+	if err != nil {
+		return PrestigeQuote{}, err
+	}
+
+	quote := PrestigeQuote{
+		LayerID:  layer.ID,
+		Currency: layer.Currency,
+		Level:    player.GetPrestigeLevel(layer.ID),
+	}
+
+	// Престиж сбросил бы забег испытания вместе с его ограничениями: сначала испытание
+	// нужно пройти или покинуть
+	if player.State.ActiveChallenge != "" {
+		quote.Reason = "not available during a challenge"
+		return quote, nil
+	}
+
+	evaluator := NewExpressionEvaluator(player)
+	if !g.requirementsMet(evaluator, layer.Reqs) {
+		quote.Reason = "requirements not met"
+		return quote, nil
+	}
+	if cost := g.calculateCost(player, evaluator, layer.ID, 0); len(cost) > 0 && !player.CanAfford(cost) {
+		quote.Reason = "cannot afford prestige cost"
+		return quote, nil
+	}
+
+	if layer.Formula != "" {
+		gain, err := evaluator.Evaluate(layer.Formula)
+		if err != nil {
+			return quote, fmt.Errorf("error evaluating prestige formula of %s: %w", layer.ID, err)
+		}
+		// Формула задает, сколько валюты заработано за все время, поэтому уже выданное вычитается
+		quote.Gain = bignum.FromFloat(gain).Floor().Sub(player.State.PrestigeAwarded[layer.ID])
+		if layer.Currency != "" && quote.Gain.Sign() <= 0 {
+			quote.Gain = bignum.Zero()
+			quote.Reason = "nothing to gain yet"
+			return quote, nil
+		}
+	}
+
+	quote.Available = true
+	return quote, nil
+}
+
+// PerformPrestige выполняет престиж слоя layerID: начисляет валюту слоя, сбрасывает
+// прогресс в пределах слоя и все нижние слои. Во время испытания престиж недоступен
+func (g *Game) PerformPrestige(player *Player, layerID string) error {
+	quote, err := g.QuotePrestige(player, layerID)
+	if err != nil {
+		return err
+	}
+	if !quote.Available {
+		return fmt.Errorf("cannot prestige %s: %s", layerID, quote.Reason)
+	}
+	layer, _ := g.ContentSystem.GetPrestigeLayer(layerID)
+	layers := g.ContentSystem.GetPrestigeLayers()
+
+	if cost := g.calculateCost(player, NewExpressionEvaluator(player), layer.ID, 0); len(cost) > 0 {
+		player.SpendResources(cost)
+	}
+
+	player.recordPrestige(layer.ID)
+	player.ResetProgress(layer.scope(layers))
+	lower := make(map[string]bool)
+	for _, other := range layers {
+		if other.Tier < layer.Tier {
+			lower[other.ID] = true
+			player.State.PrestigeLayers[other.ID] = 0
+		}
+	}
+	// Бонусы нижних слоев сбрасываются вместе с их счетчиками
+	modifiers := player.State.Modifiers[:0]
+	for _, modifier := range player.State.Modifiers {
+		if !lower[modifier.Source] {
+			modifiers = append(modifiers, modifier)
+		}
+	}
+	player.State.Modifiers = modifiers
+
+	if layer.Currency != "" {
+		player.AddItem(layer.Currency, quote.Gain)
+		player.State.PrestigeAwarded[layer.ID] = player.State.PrestigeAwarded[layer.ID].Add(quote.Gain)
+	}
+	player.State.PrestigeLayers[layer.ID]++
+	player.State.Prestige++
+	g.grantPermanentEffects(player, layer.ID, layer.Effects)
+	player.RecalculateState()
+
+	log.Printf("Player %s performed prestige %s (level %d)", player.ID, layer.ID, player.GetPrestigeLevel(layer.ID))
+	player.AddLog(fmt.Sprintf("Performed prestige: %s", layer.Name))
+	g.EventSystem.Emit("Prestige", map[string]interface{}{
+		"PlayerID":      player.ID,
+		"LayerID":       layer.ID,
+		"PrestigeLevel": player.GetPrestigeLevel(layer.ID),
+		"Gain":          quote.Gain,
+	})
+
+	return nil
+}
+
+// GetPrestigeLevel возвращает, сколько раз игрок выполнил престиж слоя
+func (p *Player) GetPrestigeLevel(layerID string) int {
+	return p.State.PrestigeLayers[layerID]
+}
+
+// toStringSlice преобразует список строк из YAML
+func toStringSlice(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(list))
+	for _, entry := range list {
+		if str, ok := entry.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
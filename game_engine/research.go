@@ -0,0 +1,264 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// researchRateTarget - цель эффектов yield, ускоряющих исследования: каждая единица
+// производства добавляет секунду исследования в секунду сверх базовой
+const researchRateTarget = "research"
+
+// ResearchNodeState - состояние узла дерева исследований для игрока
+type ResearchNodeState string
+
+const (
+	// ResearchLocked - не изучены предшествующие узлы или не выполнены требования
+	ResearchLocked ResearchNodeState = "locked"
+	// ResearchAvailable - узел можно начать исследовать
+	ResearchAvailable ResearchNodeState = "available"
+	// ResearchInProgress - узел исследуется сейчас
+	ResearchInProgress ResearchNodeState = "in_progress"
+	// ResearchCompleted - узел изучен
+	ResearchCompleted ResearchNodeState = "completed"
+)
+
+// ResearchNode - узел дерева исследований из категории research. Requires - узлы,
+// которые должны быть изучены раньше; изученный узел принадлежит игроку как предмет,
+// поэтому другой контент открывается через have('node') в reqs
+type ResearchNode struct {
+	ID       string
+	Name     string
+	Requires []string
+	Reqs     []string
+	Duration time.Duration
+}
+
+// ActiveResearch - исследование, которое идет у игрока
+type ActiveResearch struct {
+	ID       string        `json:"id"`
+	Progress time.Duration `json:"progress"`
+	Started  time.Time     `json:"started"`
+}
+
+// ResearchStatus описывает узел дерева исследований для интерфейса
+type ResearchStatus struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	State     ResearchNodeState `json:"state"`
+	Requires  []string          `json:"requires"`
+	Duration  time.Duration     `json:"duration"`
+	Progress  time.Duration     `json:"progress,omitempty"`
+	Remaining time.Duration     `json:"remaining,omitempty"`
+}
+
+// parseResearch создает узлы исследований и проверяет, что зависимости образуют DAG
+func parseResearch(items map[string]GameItem) (map[string]ResearchNode, error) {
+	nodes := make(map[string]ResearchNode, len(items))
+	for id, item := range items {
+		node := ResearchNode{
+			ID:       id,
+			Name:     item.Name,
+			Requires: toStringSlice(item.Properties["requires"]),
+			Reqs:     item.Reqs,
+		}
+		duration := toFloat(item.Properties["duration"])
+		if duration < 0 {
+			return nil, fmt.Errorf("research %s has negative duration", id)
+		}
+		node.Duration = time.Duration(duration * float64(time.Second))
+		nodes[id] = node
+	}
+
+	for id, node := range nodes {
+		for _, required := range node.Requires {
+			if _, ok := nodes[required]; !ok {
+				return nil, fmt.Errorf("research %s requires unknown research %s", id, required)
+			}
+		}
+	}
+	if cycle := findResearchCycle(nodes); cycle != nil {
+		return nil, fmt.Errorf("research prerequisites form a cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nodes, nil
+}
+
+// findResearchCycle ищет цикл в зависимостях обходом в глубину и возвращает его путь
+func findResearchCycle(nodes map[string]ResearchNode) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(nodes))
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		switch marks[id] {
+		case visiting:
+			for i, step := range path {
+				if step == id {
+					return append(append([]string(nil), path[i:]...), id)
+				}
+			}
+		case visited:
+			return nil
+		}
+		marks[id] = visiting
+		path = append(path, id)
+		for _, required := range nodes[id].Requires {
+			if cycle := visit(required); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		marks[id] = visited
+		return nil
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// GetResearchNode возвращает узел исследований по ID
+func (cs *ContentSystem) GetResearchNode(id string) (ResearchNode, error) {
+	node, ok := cs.research[id]
+	if !ok {
+		return ResearchNode{}, fmt.Errorf("research not found: %s", id)
+	}
+	return node, nil
+}
+
+// researchState определяет состояние узла для игрока
+func (g *Game) researchState(player *Player, evaluator *ExpressionEvaluator, node ResearchNode) ResearchNodeState {
+	if player.GetItemAmount(node.ID).Sign() > 0 {
+		return ResearchCompleted
+	}
+	if player.State.Research != nil && player.State.Research.ID == node.ID {
+		return ResearchInProgress
+	}
+	for _, required := range node.Requires {
+		if player.GetItemAmount(required).Sign() <= 0 {
+			return ResearchLocked
+		}
+	}
+	if !g.requirementsMet(evaluator, node.Reqs) {
+		return ResearchLocked
+	}
+	return ResearchAvailable
+}
+
+// researchRate возвращает скорость исследования: секунд работы за секунду времени
+func (p *Player) researchRate() float64 {
+	return 1 + p.State.RPS[researchRateTarget].Float64()
+}
+
+// StartResearch оплачивает и начинает исследование узла. Одновременно идет одно исследование
+func (g *Game) StartResearch(player *Player, researchID string) error {
+	node, err := g.ContentSystem.GetResearchNode(researchID)
+	if err != nil {
+		return err
+	}
+	if player.State.Research != nil {
+		return fmt.Errorf("already researching: %s", player.State.Research.ID)
+	}
+	evaluator := NewExpressionEvaluator(player)
+	if state := g.researchState(player, evaluator, node); state != ResearchAvailable {
+		return fmt.Errorf("research %s is %s", researchID, state)
+	}
+
+	if cost := g.calculateCost(player, evaluator, node.ID, 0); len(cost) > 0 {
+		if !player.CanAfford(cost) {
+			return fmt.Errorf("cannot afford research: %s", researchID)
+		}
+		player.SpendResources(cost)
+	}
+
+	player.State.Research = &ActiveResearch{ID: node.ID, Started: g.Clock.Now()}
+	player.AddLog(fmt.Sprintf("Research started: %s", node.Name))
+	g.EventSystem.Emit("ResearchStarted", map[string]interface{}{
+		"PlayerID":   player.ID,
+		"ResearchID": node.ID,
+	})
+
+	if node.Duration == 0 {
+		g.completeResearch(player, node)
+	}
+	return nil
+}
+
+// researchTick - этап тика, продвигающий текущее исследование
+func (g *Game) researchTick(player *Player, now time.Time, dt time.Duration) {
+	active := player.State.Research
+	if active == nil {
+		return
+	}
+	node, err := g.ContentSystem.GetResearchNode(active.ID)
+	if err != nil {
+		log.Printf("Dropping unknown research %s of player %s", active.ID, player.ID)
+		player.State.Research = nil
+		return
+	}
+
+	active.Progress += time.Duration(float64(dt) * player.researchRate())
+	if active.Progress >= node.Duration {
+		g.completeResearch(player, node)
+	}
+}
+
+// completeResearch отдает игроку изученный узел и применяет его эффекты
+func (g *Game) completeResearch(player *Player, node ResearchNode) {
+	player.State.Research = nil
+	if item := player.GetItem(node.ID); item != nil {
+		item.Amount = item.Amount.Add(bignum.One())
+	}
+	player.RecalculateState()
+
+	log.Printf("Player %s completed research %s", player.ID, node.ID)
+	player.AddLog(fmt.Sprintf("Research completed: %s", node.Name))
+	g.EventSystem.Emit("ResearchCompleted", map[string]interface{}{
+		"PlayerID":   player.ID,
+		"ResearchID": node.ID,
+	})
+}
+
+// GetResearch возвращает состояние всех узлов дерева исследований
+func (g *Game) GetResearch(player *Player) []ResearchStatus {
+	evaluator := NewExpressionEvaluator(player)
+	ids := g.ContentSystem.GetSortedIDs("research")
+	statuses := make([]ResearchStatus, 0, len(ids))
+	for _, id := range ids {
+		node, err := g.ContentSystem.GetResearchNode(id)
+		if err != nil {
+			continue
+		}
+		status := ResearchStatus{
+			ID:       id,
+			Name:     node.Name,
+			State:    g.researchState(player, evaluator, node),
+			Requires: node.Requires,
+			Duration: node.Duration,
+		}
+		if status.State == ResearchInProgress {
+			status.Progress = player.State.Research.Progress
+			status.Remaining = time.Duration(float64(node.Duration-status.Progress) / player.researchRate())
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,124 @@
+package game_engine
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// defaultSellRatio - доля стоимости, возвращаемая при продаже, если в конфигурации не задан sell_ratio
+const defaultSellRatio = 0.5
+
+// sellableCategories - категории, предметы которых продаются по умолчанию. Предметы других
+// категорий продаются, только если у них задано sellable: true
+var sellableCategories = map[string]bool{"buildings": true, "upgrades": true}
+
+// SellQuote - результат расчета продажи: сколько единиц, какой возврат и сколько останется у игрока
+type SellQuote struct {
+	ItemID    string                   `json:"itemId"`
+	Count     int                      `json:"count"`
+	Owned     int                      `json:"owned"`
+	Resulting int                      `json:"resulting"`
+	Refund    map[string]bignum.Number `json:"refund"`
+}
+
+// sellRatio возвращает долю стоимости, которую возвращает продажа предмета:
+// sell_ratio предмета, затем sell_ratio из настроек игры
+func (g *Game) sellRatio(item GameItem) float64 {
+	if ratio, ok := item.Properties["sell_ratio"]; ok {
+		return toFloat(ratio)
+	}
+	if g.Settings.SellRatio > 0 {
+		return g.Settings.SellRatio
+	}
+	return defaultSellRatio
+}
+
+// sellable проверяет, можно ли продать предмет: свойство sellable, затем категория
+func sellable(item GameItem) bool {
+	if sellable, ok := item.Properties["sellable"].(bool); ok {
+		return sellable
+	}
+	return sellableCategories[item.Type]
+}
+
+// QuoteSell рассчитывает продажу count единиц предмета, не изменяя состояние игрока.
+// Возвращается доля того, сколько последние count единиц стоят сейчас со скидками, но не дороже
+// базовой кривой: скидки уменьшают возврат, чтобы покупка и продажа не давали прибыли, а штрафы
+// испытаний его не увеличивают
+func (g *Game) QuoteSell(player *Player, itemID string, count int) (SellQuote, error) {
+	content, ok := g.ContentSystem.GetItem(itemID)
+	if !ok || player.GetItem(itemID) == nil {
+		return SellQuote{}, fmt.Errorf("item not found: %s", itemID)
+	}
+	if !sellable(content) {
+		return SellQuote{}, fmt.Errorf("item cannot be sold: %s", itemID)
+	}
+	if count <= 0 {
+		return SellQuote{}, fmt.Errorf("sell quantity must be positive: %d", count)
+	}
+
+	owned := player.GetItemCount(itemID)
+	if count > owned {
+		return SellQuote{}, fmt.Errorf("cannot sell %d of %s: only %d owned", count, itemID, owned)
+	}
+
+	evaluator := NewExpressionEvaluator(player)
+	cost, err := curveTotalCost(evaluator, itemID, content.Cost, content.CostScaling, owned-count, count)
+	if err != nil {
+		return SellQuote{}, err
+	}
+	discounted, err := g.totalCost(player, evaluator, itemID, owned-count, count)
+	if err != nil {
+		return SellQuote{}, err
+	}
+	for resource, amount := range discounted {
+		cost[resource] = bignum.Min(cost[resource], amount)
+	}
+	ratio := g.sellRatio(content)
+	refund := make(map[string]bignum.Number, len(cost))
+	for resource, amount := range cost {
+		refund[resource] = amount.MulFloat(ratio)
+	}
+
+	return SellQuote{
+		ItemID:    itemID,
+		Count:     count,
+		Owned:     owned,
+		Resulting: owned - count,
+		Refund:    refund,
+	}, nil
+}
+
+// SellBulk продает несколько единиц предмета одной операцией. Возврат не считается
+// заработком и ограничен хранилищем
+func (g *Game) SellBulk(player *Player, itemID string, count int) (SellQuote, error) {
+	quote, err := g.QuoteSell(player, itemID, count)
+	if err != nil {
+		return quote, err
+	}
+
+	item := player.GetItem(itemID)
+	item.Amount = item.Amount.Sub(bignum.FromInt(quote.Count))
+	for resource, amount := range quote.Refund {
+		if res := player.GetItem(resource); res != nil {
+			res.Amount = player.clampToCap(resource, res.Amount, res.Amount.Add(amount))
+		}
+	}
+	player.recordSold(itemID, quote.Count)
+	log.Printf("Player %s sold item: %s x%d (now have %d)", player.ID, item.Name, quote.Count, quote.Resulting)
+	player.AddLog(fmt.Sprintf("Sold item: %s x%d (now have %d)", item.Name, quote.Count, quote.Resulting))
+
+	player.RecalculateState()
+	g.checkMilestones(player)
+
+	g.EventSystem.Emit("BuildingSold", map[string]interface{}{
+		"PlayerID": player.ID,
+		"ItemID":   itemID,
+		"Amount":   quote.Resulting,
+		"Count":    quote.Count,
+		"Refund":   quote.Refund,
+	})
+	return quote, nil
+}
@@ -0,0 +1,79 @@
+package game_engine
+
+import (
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+func TestSellRefundNeverExceedsPurchasePrice(t *testing.T) {
+	game, player := newTestGame(t)
+	player.State.Items["pan"].Amount = bignum.FromInt(60)
+	player.State.Items["haggling"].Amount = bignum.FromInt(5)
+	player.State.Items["money"].Amount = bignum.FromFloat(1e9)
+	game.checkMilestones(player)
+	if !player.State.Milestones["pan_50"] {
+		t.Fatal("pan_50 should be reached")
+	}
+
+	money := player.GetItemAmount("money")
+	bought, err := game.BuyBulk(player, "pan", PurchaseRequest{Mode: PurchaseCount, Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sold, err := game.SellBulk(player, "pan", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sold.Refund["money"].Gt(bought.Cost["money"]) {
+		t.Errorf("refund %v exceeds purchase price %v", sold.Refund["money"], bought.Cost["money"])
+	}
+	if got := player.GetItemAmount("money"); got.Gt(money) {
+		t.Errorf("buying and selling made money: %v -> %v", money, got)
+	}
+	want := bought.Cost["money"].MulFloat(game.sellRatio(mustItem(t, game, "pan")))
+	if !closeTo(sold.Refund["money"], want) {
+		t.Errorf("refund = %v, want %v", sold.Refund["money"], want)
+	}
+}
+
+func TestSellRefundIgnoresChallengePenalty(t *testing.T) {
+	game, player := newTestGame(t)
+	base, err := game.QuoteSell(player, "pan", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Inflation утраивает цены, но возврат не дороже базовой кривой
+	player.State.ActiveChallenge = "inflation"
+	inflated, err := game.QuoteSell(player, "pan", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inflated.Refund["money"].Eq(base.Refund["money"]) {
+		t.Errorf("refund during inflation = %v, want %v", inflated.Refund["money"], base.Refund["money"])
+	}
+}
+
+func TestSellableCategories(t *testing.T) {
+	game, player := newTestGame(t)
+	for _, id := range []string{"gold", "golden_touch", "foreman", "first_gold"} {
+		if item := player.GetItem(id); item != nil {
+			item.Amount = bignum.FromInt(1)
+		}
+		if _, err := game.QuoteSell(player, id, 1); err == nil {
+			t.Errorf("%s must not be sellable", id)
+		}
+	}
+	if _, err := game.QuoteSell(player, "pan", 1); err != nil {
+		t.Errorf("buildings must be sellable: %v", err)
+	}
+}
+
+func mustItem(t *testing.T, game *Game, id string) GameItem {
+	t.Helper()
+	item, ok := game.ContentSystem.GetItem(id)
+	if !ok {
+		t.Fatalf("item not found: %s", id)
+	}
+	return item
+}
@@ -0,0 +1,141 @@
+package game_engine
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// defaultShinyJitter - разброс интервала появления относительно frequency (±50%)
+const defaultShinyJitter = 0.5
+
+// shinyTick - этап тика, который создает и убирает "блестящие" объекты игрока по расписанию
+func (g *Game) shinyTick(player *Player, now time.Time, dt time.Duration) {
+	for _, id := range g.ContentSystem.GetSortedIDs("shinies") {
+		shiny, err := g.ContentSystem.GetContent("shinies", id)
+		if err != nil {
+			continue
+		}
+		g.updateShiny(player, shiny, now)
+	}
+}
+
+// updateShiny продвигает расписание одного объекта до момента now. За длинный промежуток
+// (например, оффлайн) объект может несколько раз появиться и исчезнуть
+func (g *Game) updateShiny(player *Player, shiny GameItem, now time.Time) {
+	state := player.GetShinyState(shiny.ID)
+	if state.NextSpawn.IsZero() && !state.Active {
+		state.NextSpawn = now.Add(g.shinyInterval(shiny))
+	}
+
+	for {
+		if state.Active {
+			if state.ExpiresAt.IsZero() || now.Before(state.ExpiresAt) {
+				break
+			}
+			state.Active = false
+			state.NextSpawn = state.ExpiresAt.Add(g.shinyInterval(shiny))
+			g.EventSystem.Emit("ShinyExpired", map[string]interface{}{
+				"PlayerID": player.ID,
+				"ShinyID":  shiny.ID,
+			})
+			continue
+		}
+
+		if now.Before(state.NextSpawn) {
+			break
+		}
+		state = g.spawnedShinyState(shiny, state.NextSpawn)
+		g.EventSystem.Emit("ShinySpawned", map[string]interface{}{
+			"PlayerID":  player.ID,
+			"ShinyID":   shiny.ID,
+			"ExpiresAt": state.ExpiresAt,
+		})
+	}
+
+	player.SetShinyState(shiny.ID, state)
+}
+
+// spawnedShinyState возвращает состояние объекта, появившегося в момент at.
+// Отрицательная или нулевая duration означает, что объект ждет, пока его не заберут
+func (g *Game) spawnedShinyState(shiny GameItem, at time.Time) ShinyState {
+	state := ShinyState{Active: true, LastSpawn: at}
+	if duration := toFloat(shiny.Properties["duration"]); duration > 0 {
+		state.ExpiresAt = at.Add(time.Duration(duration * float64(time.Second)))
+	}
+	return state
+}
+
+// shinyInterval возвращает случайный интервал до следующего появления в пределах frequency ± jitter
+func (g *Game) shinyInterval(shiny GameItem) time.Duration {
+	frequency := toFloat(shiny.Properties["frequency"])
+	if frequency <= 0 {
+		frequency = 1
+	}
+	jitter := defaultShinyJitter
+	if value, ok := shiny.Properties["jitter"]; ok {
+		jitter = toFloat(value)
+	}
+
+	seconds := frequency * (1 - jitter + 2*jitter*rand.Float64())
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// SpawnShiny немедленно создает "блестящий" объект у игрока
+func (g *Game) SpawnShiny(player *Player, shinyID string) error {
+	shiny, err := g.ContentSystem.GetContent("shinies", shinyID)
+	if err != nil {
+		return err
+	}
+	state := g.spawnedShinyState(shiny, g.Clock.Now())
+	player.SetShinyState(shinyID, state)
+	g.EventSystem.Emit("ShinySpawned", map[string]interface{}{
+		"PlayerID":  player.ID,
+		"ShinyID":   shinyID,
+		"ExpiresAt": state.ExpiresAt,
+	})
+	return nil
+}
+
+// ClaimShiny забирает активный "блестящий" объект и применяет его эффекты
+func (g *Game) ClaimShiny(player *Player, shinyID string) error {
+	shiny, err := g.ContentSystem.GetContent("shinies", shinyID)
+	if err != nil {
+		return err
+	}
+
+	now := g.Clock.Now()
+	state := player.GetShinyState(shinyID)
+	if !state.Active || (!state.ExpiresAt.IsZero() && !now.Before(state.ExpiresAt)) {
+		return fmt.Errorf("shiny is not active: %s", shinyID)
+	}
+
+	for _, effect := range shiny.Effects {
+		g.applyEffect(player, effect)
+	}
+	player.SetShinyState(shinyID, ShinyState{
+		LastSpawn: state.LastSpawn,
+		NextSpawn: now.Add(g.shinyInterval(shiny)),
+	})
+
+	player.State.Stats.both(func(stats *Statistics) {
+		stats.ShiniesClaimed++
+	})
+	player.AddLog(fmt.Sprintf("Claimed: %s", shiny.Name))
+	g.EventSystem.Emit("ShinyClaimed", map[string]interface{}{
+		"PlayerID": player.ID,
+		"ShinyID":  shinyID,
+	})
+	return nil
+}
+
+// GetActiveShinies возвращает ID активных "блестящих" объектов игрока
+func (g *Game) GetActiveShinies(player *Player) []string {
+	active := make([]string, 0)
+	for _, id := range g.ContentSystem.GetSortedIDs("shinies") {
+		if player.GetShinyState(id).Active {
+			active = append(active, id)
+		}
+	}
+	return active
+}
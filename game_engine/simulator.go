@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
 )
 
 type GameSimulator struct {
@@ -16,7 +18,7 @@ func NewGameSimulator(game *Game) *GameSimulator {
 	}
 }
 
-func (gs *GameSimulator) SimulatePlayerProgress(player *Player, days int) map[string]uint64 {
+func (gs *GameSimulator) SimulatePlayerProgress(player *Player, days int) map[string]bignum.Number {
 	for i := 0; i < days; i++ {
 		gs.simulateDay(player)
 		log.Printf("   Buildings: %v", player.GetBuildings())
@@ -24,7 +26,7 @@ func (gs *GameSimulator) SimulatePlayerProgress(player *Player, days int) map[st
 		log.Printf("   RPS: %+v", player.State.RPS)
 	}
 
-	return player.State.Resources
+	return player.GetResources()
 }
 
 func (gs *GameSimulator) simulateDay(player *Player) {
@@ -41,11 +43,8 @@ func (gs *GameSimulator) simulateDay(player *Player) {
 		gs.game.Buy(player, name)
 	}
 
-	// Обновление состояния игрока
-	gs.game.updatePlayer(player)
-
 	// Симуляция прошедшего времени
-	player.State.LastSaveTime = player.State.LastSaveTime.Add(24 * time.Hour)
+	gs.game.advance(player, player.State.LastSaveTime.Add(24*time.Hour))
 }
 
 func (gs *GameSimulator) RunSimulation(numPlayers, days int) {
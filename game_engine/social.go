@@ -2,6 +2,8 @@ package game_engine
 
 import (
 	"fmt"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
 )
 
 type SocialSystem struct {
@@ -12,7 +14,7 @@ func NewSocialSystem(game *Game) *SocialSystem {
 	return &SocialSystem{game: game}
 }
 
-func (ss *SocialSystem) Trade(fromPlayer, toPlayer *Player, offerResources, requestResources map[string]float64) error {
+func (ss *SocialSystem) Trade(fromPlayer, toPlayer *Player, offerResources, requestResources map[string]bignum.Number) error {
 	if !fromPlayer.CanAfford(offerResources) {
 		return fmt.Errorf("offering player doesn't have enough resources")
 	}
@@ -0,0 +1,203 @@
+package game_engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// Statistics - счетчики игрока за один период: текущий забег или все время.
+// Prestiges растет только за все время: престиж заканчивает забег
+type Statistics struct {
+	Earned         map[string]bignum.Number `json:"earned"`
+	Spent          map[string]bignum.Number `json:"spent"`
+	Bought         map[string]int           `json:"bought"`
+	Sold           map[string]int           `json:"sold"`
+	PeakOwned      map[string]int           `json:"peakOwned"`
+	Prestiges      int                      `json:"prestiges"`
+	TimePlayed     time.Duration            `json:"timePlayed"`
+	Clicks         int                      `json:"clicks"`
+	ShiniesClaimed int                      `json:"shiniesClaimed"`
+}
+
+// PlayerStats - статистика текущего забега (сбрасывается престижем) и за все время.
+// FastestRuns хранит самый быстрый забег, закончившийся престижем каждого слоя
+type PlayerStats struct {
+	Run         Statistics               `json:"run"`
+	AllTime     Statistics               `json:"allTime"`
+	FastestRuns map[string]time.Duration `json:"fastestRuns"`
+}
+
+func newStatistics() Statistics {
+	return Statistics{
+		Earned:    make(map[string]bignum.Number),
+		Spent:     make(map[string]bignum.Number),
+		Bought:    make(map[string]int),
+		Sold:      make(map[string]int),
+		PeakOwned: make(map[string]int),
+	}
+}
+
+func newPlayerStats() PlayerStats {
+	return PlayerStats{
+		Run:         newStatistics(),
+		AllTime:     newStatistics(),
+		FastestRuns: make(map[string]time.Duration),
+	}
+}
+
+// init заполняет пустые поля статистики загруженного игрока
+func (s *PlayerStats) init() {
+	for _, stats := range []*Statistics{&s.Run, &s.AllTime} {
+		fresh := newStatistics()
+		if stats.Earned == nil {
+			stats.Earned = fresh.Earned
+		}
+		if stats.Spent == nil {
+			stats.Spent = fresh.Spent
+		}
+		if stats.Bought == nil {
+			stats.Bought = fresh.Bought
+		}
+		if stats.Sold == nil {
+			stats.Sold = fresh.Sold
+		}
+		if stats.PeakOwned == nil {
+			stats.PeakOwned = fresh.PeakOwned
+		}
+	}
+	if s.FastestRuns == nil {
+		s.FastestRuns = make(map[string]time.Duration)
+	}
+}
+
+// both вызывает fn для статистики забега и статистики за все время
+func (s *PlayerStats) both(fn func(stats *Statistics)) {
+	fn(&s.Run)
+	fn(&s.AllTime)
+}
+
+func (p *Player) recordSpent(resource string, amount bignum.Number) {
+	if amount.Sign() <= 0 {
+		return
+	}
+	p.State.Stats.both(func(stats *Statistics) {
+		stats.Spent[resource] = stats.Spent[resource].Add(amount)
+	})
+}
+
+func (p *Player) recordBought(itemID string, count int) {
+	p.State.Stats.both(func(stats *Statistics) {
+		stats.Bought[itemID] += count
+	})
+	p.recordOwned(itemID)
+}
+
+func (p *Player) recordSold(itemID string, count int) {
+	p.State.Stats.both(func(stats *Statistics) {
+		stats.Sold[itemID] += count
+	})
+}
+
+// recordOwned обновляет пик количества предмета
+func (p *Player) recordOwned(itemID string) {
+	owned := p.GetItemCount(itemID)
+	p.State.Stats.both(func(stats *Statistics) {
+		if owned > stats.PeakOwned[itemID] {
+			stats.PeakOwned[itemID] = owned
+		}
+	})
+}
+
+// recordPrestige учитывает престиж слоя: запоминает самый быстрый забег и начинает новый
+func (p *Player) recordPrestige(layerID string) {
+	run := p.State.Stats.Run.TimePlayed
+	if fastest, ok := p.State.Stats.FastestRuns[layerID]; !ok || run < fastest {
+		p.State.Stats.FastestRuns[layerID] = run
+	}
+	p.State.Stats.AllTime.Prestiges++
+	p.State.Stats.Run = newStatistics()
+}
+
+// creditProducers распределяет полученный ресурс между производящими его предметами
+// пропорционально их базовому вкладу
+func (p *Player) creditProducers(resource string, gained bignum.Number) {
+	breakdown, ok := p.Breakdown[resource]
+	if !ok || gained.Sign() <= 0 || breakdown.Base.Sign() <= 0 {
+		return
+	}
+	for _, source := range breakdown.Sources {
+		p.creditItem(source.ItemID, resource, gained.Mul(source.Yield).Div(breakdown.Base))
+	}
+}
+
+// creditItem записывает в PlayerItem.ResourceEarned, сколько ресурса принес предмет
+func (p *Player) creditItem(itemID, resource string, amount bignum.Number) {
+	item := p.State.Items[itemID]
+	if item == nil || amount.Sign() <= 0 {
+		return
+	}
+	if item.ResourceEarned == nil {
+		item.ResourceEarned = make(map[string]bignum.Number)
+	}
+	item.ResourceEarned[resource] = item.ResourceEarned[resource].Add(amount)
+}
+
+// statsTick - этап тика, учитывающий время игры
+func (g *Game) statsTick(player *Player, now time.Time, dt time.Duration) {
+	player.State.Stats.both(func(stats *Statistics) {
+		stats.TimePlayed += dt
+	})
+}
+
+// RecordClick учитывает клик игрока. Вызывается интерфейсом на каждое ручное действие
+func (g *Game) RecordClick(player *Player) {
+	player.State.Stats.both(func(stats *Statistics) {
+		stats.Clicks++
+	})
+}
+
+// GetStatistics возвращает статистику игрока
+func (g *Game) GetStatistics(player *Player) PlayerStats {
+	return player.State.Stats
+}
+
+// statisticsParameters добавляет статистику в параметры выражений: [gold:earned], [gold:run_earned],
+// [gold:spent], [pan:bought], [pan:sold], [pan:peak] и общие [stat:time_played], [stat:clicks],
+// [stat:shinies] с парными [run:...] для текущего забега, а также [stat:prestiges] и
+// [stat:fastest_<слой>]. До первого престижа слоя [stat:fastest_<слой>] равен +Inf, поэтому
+// условия вида "< 600" не выполняются. Заработок и траты дополнительно доступны логарифмом:
+// [gold:earned_log], [gold:spent_log]
+func (p *Player) statisticsParameters(params map[string]interface{}) {
+	stats := p.State.Stats
+	for name := range p.State.Items {
+		params[name+":earned"] = stats.AllTime.Earned[name].Float64()
+		params[name+":run_earned"] = stats.Run.Earned[name].Float64()
+		params[name+":spent"] = stats.AllTime.Spent[name].Float64()
+		params[name+":run_spent"] = stats.Run.Spent[name].Float64()
+		params[name+":earned_log"] = stats.AllTime.Earned[name].Log10()
+		params[name+":run_earned_log"] = stats.Run.Earned[name].Log10()
+		params[name+":spent_log"] = stats.AllTime.Spent[name].Log10()
+		params[name+":run_spent_log"] = stats.Run.Spent[name].Log10()
+		params[name+":bought"] = float64(stats.AllTime.Bought[name])
+		params[name+":run_bought"] = float64(stats.Run.Bought[name])
+		params[name+":sold"] = float64(stats.AllTime.Sold[name])
+		params[name+":peak"] = float64(stats.AllTime.PeakOwned[name])
+	}
+
+	params["stat:prestiges"] = float64(stats.AllTime.Prestiges)
+	for prefix, period := range map[string]Statistics{"stat": stats.AllTime, "run": stats.Run} {
+		params[prefix+":time_played"] = period.TimePlayed.Seconds()
+		params[prefix+":clicks"] = float64(period.Clicks)
+		params[prefix+":shinies"] = float64(period.ShiniesClaimed)
+	}
+	if p.Config != nil {
+		for _, layer := range p.Config.GetPrestigeLayers() {
+			params["stat:fastest_"+layer.ID] = math.Inf(1)
+		}
+	}
+	for layer, fastest := range stats.FastestRuns {
+		params["stat:fastest_"+layer] = fastest.Seconds()
+	}
+}
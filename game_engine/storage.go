@@ -0,0 +1,94 @@
+package game_engine
+
+import (
+	"time"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+// EffectCap увеличивает вместимость ресурса Target на Value за каждую единицу предмета
+const EffectCap = "cap"
+
+// StorageStatus описывает заполненность хранилища ресурса для интерфейса
+type StorageStatus struct {
+	Resource string        `json:"resource"`
+	Amount   bignum.Number `json:"amount"`
+	Cap      bignum.Number `json:"cap"`
+	Rate     bignum.Number `json:"rate"`
+	Full     bool          `json:"full"`
+	// Filling - ресурс прибывает; TimeToFull - сколько осталось до заполнения при текущей скорости
+	Filling    bool          `json:"filling"`
+	TimeToFull time.Duration `json:"timeToFull,omitempty"`
+}
+
+// calculateCaps пересчитывает вместимость ресурсов: базовый cap ресурса из конфигурации
+// плюс эффекты cap принадлежащих игроку предметов. Ресурсы без базового cap не ограничены
+func (p *Player) calculateCaps() {
+	caps := make(map[string]bignum.Number)
+	if p.Config == nil {
+		p.State.ResourceMaxes = caps
+		return
+	}
+	for id, resource := range p.Config.GetAllContent("resources") {
+		if base := toFloat(resource.Properties["cap"]); base > 0 {
+			caps[id] = bignum.FromFloat(base)
+		}
+	}
+
+	p.forEachPassiveEffect(EffectCap, func(effect Effect, value, count float64) {
+		// Эффекты cap на ресурсы без базового cap отвергает ValidateConfig
+		current, ok := caps[effect.Target]
+		if !ok {
+			return
+		}
+		caps[effect.Target] = current.Add(bignum.FromFloat(value * count))
+	})
+	p.State.ResourceMaxes = caps
+}
+
+// clampToCap ограничивает прирост ресурса вместимостью. Запас, уже превышающий
+// вместимость (например, после ее уменьшения), не отнимается
+func (p *Player) clampToCap(resource string, before, after bignum.Number) bignum.Number {
+	maxAmount, ok := p.State.ResourceMaxes[resource]
+	if !ok || after.Lte(maxAmount) || after.Lt(before) {
+		return after
+	}
+	return bignum.Max(before, maxAmount)
+}
+
+// freeSpace возвращает, сколько ресурса еще поместится, и false для неограниченных ресурсов
+func (p *Player) freeSpace(resource string) (bignum.Number, bool) {
+	maxAmount, ok := p.State.ResourceMaxes[resource]
+	if !ok {
+		return bignum.Number{}, false
+	}
+	return bignum.Max(maxAmount.Sub(p.GetItemAmount(resource)), bignum.Zero()), true
+}
+
+// GetStorage возвращает заполненность и время до заполнения всех ограниченных ресурсов
+func (g *Game) GetStorage(player *Player) []StorageStatus {
+	breakdowns := g.GetProductionBreakdown(player)
+	statuses := make([]StorageStatus, 0, len(player.State.ResourceMaxes))
+	for _, id := range g.ContentSystem.GetSortedIDs("resources") {
+		maxAmount, ok := player.State.ResourceMaxes[id]
+		if !ok {
+			continue
+		}
+		status := StorageStatus{
+			Resource: id,
+			Amount:   player.GetItemAmount(id),
+			Cap:      maxAmount,
+		}
+		if breakdown, ok := breakdowns[id]; ok {
+			status.Rate = breakdown.Net
+		}
+		status.Full = status.Amount.Gte(maxAmount)
+		status.Filling = !status.Full && status.Rate.Sign() > 0
+		if status.Filling {
+			seconds := maxAmount.Sub(status.Amount).Div(status.Rate).Float64()
+			status.TimeToFull = time.Duration(seconds * float64(time.Second))
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,38 @@
+package game_engine
+
+import (
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/bignum"
+)
+
+func TestGrantRespectsCap(t *testing.T) {
+	game, player := newTestGame(t)
+	limit := player.State.ResourceMaxes["gold"]
+	if limit.IsZero() {
+		t.Fatal("gold should be capped in the sample config")
+	}
+	player.State.Items["gold"].Amount = limit.Sub(bignum.FromInt(100))
+	earned := player.State.Stats.AllTime.Earned["gold"]
+
+	game.applyEffect(player, Effect{Type: "grant", Target: "gold", Value: 1000})
+
+	if got := player.GetItemAmount("gold"); !got.Eq(limit) {
+		t.Errorf("gold = %v, want the cap %v", got, limit)
+	}
+	if got := player.State.Stats.AllTime.Earned["gold"].Sub(earned); !got.Eq(bignum.FromInt(100)) {
+		t.Errorf("earned %v, want only the 100 that fit", got)
+	}
+}
+
+func TestAddItemKeepsStockAboveCap(t *testing.T) {
+	_, player := newTestGame(t)
+	over := player.State.ResourceMaxes["gold"].Add(bignum.FromInt(500))
+	player.State.Items["gold"].Amount = over
+
+	player.AddItem("gold", bignum.FromInt(10))
+
+	if got := player.GetItemAmount("gold"); !got.Eq(over) {
+		t.Errorf("gold = %v, want stock above the cap kept at %v", got, over)
+	}
+}
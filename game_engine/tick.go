@@ -0,0 +1,77 @@
+package game_engine
+
+import (
+	"time"
+)
+
+const (
+	// defaultTickRate используется, если в конфигурации не задан tick_rate
+	defaultTickRate = time.Second
+	// offlineThreshold - промежуток, начиная с которого тик обрабатывается как оффлайн-прогресс,
+	// а игрок получает сообщение и сводку "пока вас не было"
+	offlineThreshold = time.Minute
+)
+
+// TickHandler - этап обработки тика. now - момент, до которого продвигается игрок,
+// dt - время, прошедшее с предыдущего тика
+type TickHandler func(player *Player, now time.Time, dt time.Duration)
+
+// RegisterTickHandler добавляет этап в конец конвейера тика
+func (g *Game) RegisterTickHandler(handler TickHandler) {
+	g.tickHandlers = append(g.tickHandlers, handler)
+}
+
+// SetClock подменяет источник времени игры и системы контента
+func (g *Game) SetClock(clock Clock) {
+	g.Clock = clock
+	g.ContentSystem.SetClock(clock)
+}
+
+// TickRate возвращает период тика, заданный в конфигурации
+func (g *Game) TickRate() time.Duration {
+	if g.Settings.TickRate > 0 {
+		return g.Settings.TickRate
+	}
+	return defaultTickRate
+}
+
+// Tick продвигает состояние игрока до момента now. Длинные промежутки
+// (например, после простоя сервера) обрабатываются как оффлайн-прогресс.
+func (g *Game) Tick(player *Player, now time.Time) {
+	dt := now.Sub(player.State.LastSaveTime)
+	if player.State.LastSaveTime.IsZero() || dt <= 0 {
+		player.State.LastSaveTime = now
+		return
+	}
+	if dt > offlineThreshold {
+		g.ApplyOfflineProgress(player, now)
+		return
+	}
+
+	g.advance(player, now)
+}
+
+// advance прогоняет все этапы конвейера тика для промежутка от последнего обновления игрока до now
+func (g *Game) advance(player *Player, now time.Time) {
+	dt := now.Sub(player.State.LastSaveTime)
+	g.produceWithBoosts(player, player.State.LastSaveTime, now)
+	g.convert(player, dt)
+	for _, handler := range g.tickHandlers {
+		handler(player, now, dt)
+	}
+	player.State.LastSaveTime = now
+}
+
+// produce начисляет производство игрока за промежуток dt с учетом лимитов ресурсов
+func (g *Game) produce(player *Player, dt time.Duration) {
+	for id, rate := range player.State.RPS {
+		item, ok := player.State.Items[id]
+		if !ok {
+			continue
+		}
+		before := item.Amount
+		item.Amount = player.clampToCap(id, before, before.Add(rate.MulFloat(dt.Seconds())))
+		player.earn(id, item.Amount.Sub(before))
+		player.creditProducers(id, item.Amount.Sub(before))
+	}
+}
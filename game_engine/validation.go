@@ -0,0 +1,635 @@
+package game_engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ralist/game_engine/game_engine/config"
+)
+
+// knownEffectTypes - типы эффектов, которые умеет применять движок
+var knownEffectTypes = map[string]bool{
+	"yield":          true,
+	"grant":          true,
+	"spawn":          true,
+	"convert":        true,
+	"boost":          true,
+	EffectCost:       true,
+	EffectOfflineCap: true,
+	EffectStarting:   true,
+	EffectCap:        true,
+	EffectUnlock:     true,
+}
+
+// statisticsSuffixes - суффиксы параметров выражений вида [gold:earned]
+var statisticsSuffixes = map[string]bool{
+	"log": true, "max": true, "max_log": true, "ps": true, "ps_log": true,
+	"earned": true, "run_earned": true, "spent": true, "run_spent": true,
+	"earned_log": true, "run_earned_log": true, "spent_log": true, "run_spent_log": true,
+	"bought": true, "run_bought": true, "sold": true, "peak": true,
+}
+
+// playerStatistics - общие параметры статистики вида [stat:clicks] и [run:clicks].
+// prestiges есть только в [stat:...]: престиж заканчивает забег
+var playerStatistics = map[string]bool{"prestiges": true, "time_played": true, "clicks": true, "shinies": true}
+
+// ValidationError - ошибка конфигурации с путем YAML и номером строки
+type ValidationError struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s (line %d): %s", e.Path, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors - все ошибки, найденные при проверке конфигурации
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("config has %d errors:", len(e)))
+	for _, err := range e {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// configValidator проверяет контент до разбора и собирает все ошибки сразу.
+// capped - ресурсы с базовым cap: только их вместимость могут поднимать эффекты cap
+type configValidator struct {
+	lines  config.LineIndex
+	ids    map[string]string
+	capped map[string]bool
+	errors ValidationErrors
+}
+
+// ValidateConfig проверяет конфигурацию: ссылки на ресурсы и предметы, типы эффектов,
+// числовые поля и выражения. Возвращает ValidationErrors со всеми найденными ошибками
+func ValidateConfig(cfg *config.GameConfig) error {
+	v := &configValidator{lines: cfg.Lines, ids: make(map[string]string), capped: make(map[string]bool)}
+	if cfg.Settings.SellRatio < 0 || cfg.Settings.SellRatio > 1 {
+		v.fail("settings.sell_ratio", "must be between 0 and 1, got %v", cfg.Settings.SellRatio)
+	}
+
+	categories := make([]string, 0, len(cfg.Content))
+	for category := range cfg.Content {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		for id, raw := range cfg.Content[category] {
+			if other, ok := v.ids[id]; ok {
+				v.fail(itemPath(category, id), "id %s is already used in %s", id, other)
+				continue
+			}
+			v.ids[id] = category
+			if data, ok := raw.(map[interface{}]interface{}); ok && category == "resources" && toFloat(data["cap"]) > 0 {
+				v.capped[id] = true
+			}
+		}
+	}
+
+	for _, category := range categories {
+		for id, raw := range cfg.Content[category] {
+			path := itemPath(category, id)
+			data, ok := raw.(map[interface{}]interface{})
+			if !ok {
+				v.fail(path, "must be a mapping")
+				continue
+			}
+			v.item(path, category, id, convertMapInterfaceToMapString(data))
+		}
+	}
+
+	if len(v.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		if v.errors[i].Line != v.errors[j].Line {
+			return v.errors[i].Line < v.errors[j].Line
+		}
+		return v.errors[i].Path < v.errors[j].Path
+	})
+	return v.errors
+}
+
+func itemPath(category, id string) string {
+	return fmt.Sprintf("content.%s.%s", category, id)
+}
+
+func (v *configValidator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{
+		Path:    path,
+		Line:    v.lines.Line(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// item проверяет общие поля предмета и поля его категории
+func (v *configValidator) item(path, category, id string, data map[string]interface{}) {
+	for _, field := range []string{"name", "description", "progress"} {
+		if value, ok := data[field]; ok {
+			if _, ok := value.(string); !ok {
+				v.fail(path+"."+field, "must be a string")
+			}
+		}
+	}
+	for _, field := range []string{"hidden", "sellable"} {
+		if value, ok := data[field]; ok {
+			if _, ok := value.(bool); !ok {
+				v.fail(path+"."+field, "must be true or false")
+			}
+		}
+	}
+
+	if initial, ok := data["initial"]; ok {
+		if value, ok := initial.(int); !ok || value < 0 {
+			v.fail(path+".initial", "must be a non-negative integer")
+		}
+	}
+	v.number(path, data, "cap", 0, false)
+	if ratio, ok := v.number(path, data, "sell_ratio", 0, false); ok && ratio > 1 {
+		v.fail(path+".sell_ratio", "must not exceed 1")
+	}
+	if cost, ok := data["cost"]; ok {
+		v.amounts(path+".cost", cost, true)
+	}
+	if scaling, ok := data["cost_scaling"]; ok {
+		v.costScaling(path+".cost_scaling", scaling)
+	}
+	if effects, ok := data["effects"]; ok {
+		v.effects(path+".effects", effects)
+	}
+	if reqs, ok := data["reqs"]; ok {
+		v.expressions(path+".reqs", reqs)
+	}
+	if progress, ok := data["progress"].(string); ok {
+		v.expression(path+".progress", progress)
+	}
+	if event, ok := data["event"]; ok {
+		v.reference(path+".event", event, "events")
+	}
+	if levels, ok := data["levels"]; ok {
+		v.levels(path+".levels", levels)
+	}
+	if milestones, ok := data["milestones"]; ok {
+		v.milestones(path+".milestones", milestones)
+	}
+
+	switch category {
+	case "research":
+		v.number(path, data, "duration", 0, false)
+		v.references(path+".requires", data["requires"], "research")
+	case "automators":
+		v.number(path, data, "interval", 0, true)
+		v.number(path, data, "value", 0, false)
+		v.targets(path+".targets", data["targets"])
+		if strategy, ok := data["strategy"]; ok {
+			switch AutomatorStrategy(fmt.Sprint(strategy)) {
+			case StrategyCheapest, StrategyReserve, StrategyBelow:
+			default:
+				v.fail(path+".strategy", "unknown automator strategy %v", strategy)
+			}
+		}
+	case "events":
+		v.event(path, data)
+	case "challenges":
+		v.challenge(path, data)
+	case "prestige":
+		v.prestige(path, data)
+	case "shinies":
+		v.number(path, data, "frequency", 0, true)
+		v.number(path, data, "duration", -1, false)
+		if jitter, ok := v.number(path, data, "jitter", 0, false); ok && jitter > 1 {
+			v.fail(path+".jitter", "must not exceed 1")
+		}
+	}
+}
+
+// number проверяет необязательное числовое поле: значение не меньше min
+// (строго больше, если positive). Возвращает значение, если поле задано и корректно
+func (v *configValidator) number(path string, data map[string]interface{}, field string, min float64, positive bool) (float64, bool) {
+	raw, ok := data[field]
+	if !ok {
+		return 0, false
+	}
+	value, ok := numberValue(raw)
+	switch {
+	case !ok:
+		v.fail(path+"."+field, "must be a number, got %v", raw)
+	case positive && value <= min:
+		v.fail(path+"."+field, "must be greater than %v, got %v", min, value)
+	case value < min:
+		v.fail(path+"."+field, "must be at least %v, got %v", min, value)
+	default:
+		return value, true
+	}
+	return 0, false
+}
+
+func numberValue(raw interface{}) (float64, bool) {
+	switch value := raw.(type) {
+	case int:
+		return float64(value), true
+	case float64:
+		return value, true
+	default:
+		return 0, false
+	}
+}
+
+// amounts проверяет словарь ресурс -> количество (стоимость, входы и выходы переработки)
+func (v *configValidator) amounts(path string, raw interface{}, positive bool) {
+	amounts, ok := raw.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be a mapping of resource to amount")
+		return
+	}
+	for resource := range amounts {
+		v.reference(path+"."+resource, resource, "resources")
+		v.number(path, amounts, resource, 0, positive)
+	}
+}
+
+func (v *configValidator) costScaling(path string, raw interface{}) {
+	scaling, ok := raw.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be a mapping")
+		return
+	}
+	if _, err := parseCostCurve(scaling); err != nil {
+		v.fail(path, "%v", err)
+	}
+	v.number(path, scaling, "rate", 0, true)
+	v.number(path, scaling, "power", 0, true)
+	if expression, ok := scaling["expression"].(string); ok {
+		v.expression(path+".expression", expression, "owned", "base")
+	}
+	if steps, ok := scaling["steps"].([]interface{}); ok {
+		for i, raw := range steps {
+			stepPath := fmt.Sprintf("%s.steps[%d]", path, i)
+			step, ok := raw.(map[string]interface{})
+			if !ok {
+				v.fail(stepPath, "must be a mapping with owned and multiplier")
+				continue
+			}
+			if owned, ok := step["owned"].(int); !ok || owned < 0 {
+				v.fail(stepPath+".owned", "must be a non-negative integer")
+			}
+			v.number(stepPath, step, "multiplier", 0, false)
+		}
+	}
+}
+
+// effects проверяет список эффектов. allowed ограничивает допустимые типы, если задан
+func (v *configValidator) effects(path string, raw interface{}, allowed ...string) {
+	effects, ok := raw.([]interface{})
+	if !ok {
+		v.fail(path, "must be a list of effects")
+		return
+	}
+	for i, raw := range effects {
+		effectPath := fmt.Sprintf("%s[%d]", path, i)
+		effect, ok := raw.(map[string]interface{})
+		if !ok {
+			v.fail(effectPath, "must be a mapping")
+			continue
+		}
+
+		effectType, _ := effect["type"].(string)
+		_, stage := effectStage(effectType)
+		switch {
+		case effectType == "":
+			v.fail(effectPath+".type", "is required")
+		case !stage && !knownEffectTypes[effectType]:
+			v.fail(effectPath+".type", "unknown effect type %s", effectType)
+		case len(allowed) > 0 && !containsString(allowed, effectType):
+			v.fail(effectPath+".type", "effect type %s is not allowed here", effectType)
+		}
+
+		// Переработчики задают ресурсы во входах и выходах, а запас оффлайн-времени общий
+		if target, ok := effect["target"]; ok {
+			v.target(effectPath+".target", target)
+			if id, ok := target.(string); ok && effectType == EffectCap && v.ids[id] != "" && !v.capped[id] {
+				v.fail(effectPath+".target", "cap effect targets resource %s without a base cap", id)
+			}
+		} else if effectType != "convert" && effectType != EffectOfflineCap {
+			v.fail(effectPath+".target", "is required")
+		}
+		_, hasValue := effect["value"]
+		v.number(effectPath, effect, "value", math.Inf(-1), false)
+		expression, hasExpression := effect["expression"].(string)
+		if hasExpression {
+			v.expression(effectPath+".expression", expression)
+		}
+		if (stage || effectType == "yield") && !hasValue && !hasExpression {
+			v.fail(effectPath, "%s effect needs a value or an expression", effectType)
+		}
+		if condition, ok := effect["condition"].(string); ok {
+			v.expression(effectPath+".condition", condition)
+		}
+
+		v.number(effectPath, effect, "duration", 0, effectType == "boost")
+		if stacking, ok := effect["stacking"]; ok {
+			switch BoostStacking(fmt.Sprint(stacking)) {
+			case BoostRefresh, BoostExtend, BoostStack, BoostMax:
+			default:
+				v.fail(effectPath+".stacking", "unknown stacking policy %v", stacking)
+			}
+		}
+		if effectType == "convert" {
+			for _, field := range []string{"inputs", "outputs"} {
+				if amounts, ok := effect[field]; ok {
+					v.amounts(effectPath+"."+field, amounts, true)
+				} else {
+					v.fail(effectPath+"."+field, "is required")
+				}
+			}
+		}
+		if priority, ok := effect["priority"]; ok {
+			if _, ok := priority.(int); !ok {
+				v.fail(effectPath+".priority", "must be an integer")
+			}
+		}
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *configValidator) levels(path string, raw interface{}) {
+	levels, ok := raw.([]interface{})
+	if !ok {
+		v.fail(path, "must be a list of levels")
+		return
+	}
+	for i, raw := range levels {
+		levelPath := fmt.Sprintf("%s[%d]", path, i)
+		level, ok := raw.(map[string]interface{})
+		if !ok {
+			v.fail(levelPath, "must be a mapping")
+			continue
+		}
+		v.number(levelPath, level, "target", 0, true)
+		if condition, ok := level["condition"].(string); ok {
+			v.expression(levelPath+".condition", condition)
+		}
+		if rewards, ok := level["rewards"]; ok {
+			v.amounts(levelPath+".rewards", rewards, true)
+		}
+		if effects, ok := level["effects"]; ok {
+			v.effects(levelPath+".effects", effects)
+		}
+	}
+}
+
+func (v *configValidator) milestones(path string, raw interface{}) {
+	milestones, ok := raw.([]interface{})
+	if !ok {
+		v.fail(path, "must be a list of milestones")
+		return
+	}
+	for i, raw := range milestones {
+		milestonePath := fmt.Sprintf("%s[%d]", path, i)
+		milestone, ok := raw.(map[string]interface{})
+		if !ok {
+			v.fail(milestonePath, "must be a mapping")
+			continue
+		}
+		if count, ok := milestone["count"]; ok {
+			if value, ok := count.(int); !ok || value <= 0 {
+				v.fail(milestonePath+".count", "must be a positive integer")
+			}
+		}
+		if threshold, ok := milestone["threshold"].(string); ok {
+			v.expression(milestonePath+".threshold", threshold)
+		}
+		if effects, ok := milestone["effects"]; ok {
+			v.effects(milestonePath+".effects", effects, string(StageAdditive), string(StageMultiplicative), string(StageExponent), EffectCost, EffectUnlock)
+		}
+	}
+}
+
+func (v *configValidator) event(path string, data map[string]interface{}) {
+	for _, field := range []string{"start", "end"} {
+		if _, err := toTime(data[field]); err != nil {
+			v.fail(path+"."+field, "%v", err)
+		}
+	}
+	v.reference(path+".currency", data["currency"], "resources")
+	if convertTo, ok := data["convert_to"]; ok {
+		v.reference(path+".convert_to", convertTo, "resources")
+	}
+	v.number(path, data, "conversion_rate", 0, false)
+	if rewards, ok := data["rewards"].([]interface{}); ok {
+		for i, raw := range rewards {
+			rewardPath := fmt.Sprintf("%s.rewards[%d]", path, i)
+			reward, ok := raw.(map[string]interface{})
+			if !ok {
+				v.fail(rewardPath, "must be a mapping")
+				continue
+			}
+			v.number(rewardPath, reward, "points", 0, true)
+			if effects, ok := reward["effects"]; ok {
+				v.effects(rewardPath+".effects", effects)
+			}
+		}
+	}
+}
+
+func (v *configValidator) challenge(path string, data map[string]interface{}) {
+	if goal, ok := data["goal"].(string); ok {
+		v.expression(path+".goal", goal)
+	} else {
+		v.fail(path+".goal", "is required")
+	}
+	v.targets(path+".resets", data["resets"])
+	v.targets(path+".keeps", data["keeps"])
+
+	restrictions, ok := data["restrictions"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	restrictionsPath := path + ".restrictions"
+	v.targets(restrictionsPath+".disabled", restrictions["disabled"])
+	for _, field := range []string{"cost", "production"} {
+		multipliers, ok := restrictions[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for target := range multipliers {
+			v.target(restrictionsPath+"."+field+"."+target, target)
+			v.number(restrictionsPath+"."+field, multipliers, target, 0, false)
+		}
+	}
+}
+
+func (v *configValidator) prestige(path string, data map[string]interface{}) {
+	if tier, ok := data["tier"]; ok {
+		if value, ok := tier.(int); !ok || value < 0 {
+			v.fail(path+".tier", "must be a non-negative integer")
+		}
+	}
+	if currency, ok := data["currency"]; ok {
+		v.reference(path+".currency", currency, "resources")
+	}
+	if formula, ok := data["formula"].(string); ok {
+		v.expression(path+".formula", formula)
+	}
+	v.targets(path+".resets", data["resets"])
+	v.targets(path+".keeps", data["keeps"])
+}
+
+// reference проверяет ссылку на элемент контента категории category (любой, если category пустая)
+func (v *configValidator) reference(path string, raw interface{}, category string) {
+	id, ok := raw.(string)
+	if !ok {
+		v.fail(path, "must be an id")
+		return
+	}
+	actual, ok := v.ids[id]
+	switch {
+	case !ok:
+		v.fail(path, "unknown %s %s", referenceName(category), id)
+	case category != "" && actual != category:
+		v.fail(path, "%s is in %s, expected %s", id, actual, referenceName(category))
+	}
+}
+
+func referenceName(category string) string {
+	switch category {
+	case "resources":
+		return "resource"
+	case "":
+		return "item"
+	default:
+		return category + " entry"
+	}
+}
+
+// references проверяет необязательный список ссылок на элементы категории
+func (v *configValidator) references(path string, raw interface{}, category string) {
+	if raw == nil {
+		return
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		v.fail(path, "must be a list")
+		return
+	}
+	for i, id := range list {
+		v.reference(fmt.Sprintf("%s[%d]", path, i), id, category)
+	}
+}
+
+// target проверяет цель эффекта или правила: ID предмета, категорию, "all" или скорость исследований
+func (v *configValidator) target(path string, raw interface{}) {
+	target, ok := raw.(string)
+	if !ok {
+		v.fail(path, "must be an id")
+		return
+	}
+	if target == "all" || target == researchRateTarget || v.isCategory(target) {
+		return
+	}
+	if _, ok := v.ids[target]; !ok {
+		v.fail(path, "unknown item or category %s", target)
+	}
+}
+
+// targets проверяет необязательный список целей
+func (v *configValidator) targets(path string, raw interface{}) {
+	if raw == nil {
+		return
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		v.fail(path, "must be a list")
+		return
+	}
+	for i, target := range list {
+		v.target(fmt.Sprintf("%s[%d]", path, i), target)
+	}
+}
+
+func (v *configValidator) isCategory(name string) bool {
+	for _, category := range v.ids {
+		if category == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expressions проверяет список выражений-требований
+func (v *configValidator) expressions(path string, raw interface{}) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		v.fail(path, "must be a list of expressions")
+		return
+	}
+	for i, raw := range list {
+		expression, ok := raw.(string)
+		if !ok {
+			v.fail(fmt.Sprintf("%s[%d]", path, i), "must be an expression string")
+			continue
+		}
+		v.expression(fmt.Sprintf("%s[%d]", path, i), expression)
+	}
+}
+
+// expression проверяет, что выражение разбирается и ссылается только на известные параметры.
+// extra - параметры, которые подставляет место вычисления (например, owned в формуле стоимости)
+func (v *configValidator) expression(path, expression string, extra ...string) {
+	compiled, err := compileExpression(expression)
+	if err != nil {
+		v.fail(path, "%v", err)
+		return
+	}
+	for _, name := range compiled.Vars() {
+		if !containsString(extra, name) && !v.knownParameter(name) {
+			v.fail(path, "unknown parameter %s in %q", name, expression)
+		}
+	}
+}
+
+// knownParameter проверяет имя параметра выражения по правилам getParameters
+func (v *configValidator) knownParameter(name string) bool {
+	if name == "ItemsLeft" {
+		return true
+	}
+	prefix, suffix, found := strings.Cut(name, ":")
+	if !found {
+		_, ok := v.ids[name]
+		return ok
+	}
+
+	switch prefix {
+	case "have", "no":
+		_, ok := v.ids[suffix]
+		return ok
+	case "stat", "run":
+		if playerStatistics[suffix] {
+			return prefix == "stat" || suffix != "prestiges"
+		}
+		layer, ok := strings.CutPrefix(suffix, "fastest_")
+		return ok && prefix == "stat" && v.ids[layer] == "prestige"
+	default:
+		_, ok := v.ids[prefix]
+		return ok && statisticsSuffixes[suffix]
+	}
+}
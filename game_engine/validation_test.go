@@ -0,0 +1,182 @@
+package game_engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ralist/game_engine/game_engine/config"
+)
+
+// validateYAML загружает конфигурацию из текста и возвращает ошибки валидации
+func validateYAML(t *testing.T, source string) ValidationErrors {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateConfig(cfg)
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %T: %v", err, err)
+	}
+	return errs
+}
+
+// findError возвращает ошибку по пути или проваливает тест
+func findError(t *testing.T, errs ValidationErrors, path string) ValidationError {
+	t.Helper()
+	for _, err := range errs {
+		if err.Path == path {
+			return err
+		}
+	}
+	t.Fatalf("no error at %s in:\n%v", path, errs)
+	return ValidationError{}
+}
+
+func TestValidateCapTargets(t *testing.T) {
+	errs := validateYAML(t, `content:
+  resources:
+    gold:
+      name: Gold
+      cap: 100
+    money:
+      name: Money
+  buildings:
+    vault:
+      name: Vault
+      effects:
+        - type: cap
+          target: gold
+          value: 50
+        - type: cap
+          target: money
+          value: 50
+`)
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+	err := findError(t, errs, "content.buildings.vault.effects[1].target")
+	if !strings.Contains(err.Message, "money") {
+		t.Errorf("message %q should name the resource", err.Message)
+	}
+}
+
+func TestSampleConfigIsValid(t *testing.T) {
+	source, err := os.ReadFile("config/gold_rush_config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := validateYAML(t, string(source)); len(errs) > 0 {
+		t.Errorf("sample config has errors:\n%v", errs)
+	}
+}
+
+const brokenConfig = `content:
+  resources:
+    gold:
+      name: Gold
+  buildings:
+    pan:
+      name: Gold Pan
+      cost:
+        silver: 5
+      effects:
+        - type: yield
+          target: gold
+          value: 1
+        - type: teleport
+          target: gold
+        - type: multiply
+          target: platinum
+          value: 2
+      milestones:
+        - count: 10
+          effects:
+            - type: grant
+              target: gold
+              value: 5
+  upgrades:
+    sieve:
+      name: Sieve
+      reqs:
+        - have('shovel')
+        - gold_dust >= 10
+`
+
+func TestValidateReportsBrokenReferences(t *testing.T) {
+	errs := validateYAML(t, brokenConfig)
+	tests := []struct {
+		path    string
+		line    int
+		message string
+	}{
+		{"content.buildings.pan.cost.silver", 9, "silver"},
+		{"content.buildings.pan.effects[2].target", 17, "platinum"},
+		{"content.upgrades.sieve.reqs[0]", 29, "have:shovel"},
+		{"content.upgrades.sieve.reqs[1]", 30, "gold_dust"},
+	}
+	for _, tt := range tests {
+		err := findError(t, errs, tt.path)
+		if err.Line != tt.line {
+			t.Errorf("%s: line %d, want %d", tt.path, err.Line, tt.line)
+		}
+		if !strings.Contains(err.Message, tt.message) {
+			t.Errorf("%s: message %q should mention %s", tt.path, err.Message, tt.message)
+		}
+	}
+}
+
+func TestValidateReportsBadEffectTypes(t *testing.T) {
+	errs := validateYAML(t, brokenConfig)
+	unknown := findError(t, errs, "content.buildings.pan.effects[1].type")
+	if unknown.Line != 14 || !strings.Contains(unknown.Message, "teleport") {
+		t.Errorf("unknown effect type reported as %+v", unknown)
+	}
+	disallowed := findError(t, errs, "content.buildings.pan.milestones[0].effects[0].type")
+	if disallowed.Line != 22 || !strings.Contains(disallowed.Message, "not allowed") {
+		t.Errorf("disallowed milestone effect reported as %+v", disallowed)
+	}
+}
+
+func TestValidationErrorsAreSortedByLine(t *testing.T) {
+	errs := validateYAML(t, brokenConfig)
+	for i := 1; i < len(errs); i++ {
+		if errs[i].Line < errs[i-1].Line {
+			t.Fatalf("errors out of order:\n%v", errs)
+		}
+	}
+	if !strings.Contains(errs.Error(), "line 9") {
+		t.Errorf("error text should carry line numbers:\n%v", errs)
+	}
+}
+
+func TestValidateStatisticsParameters(t *testing.T) {
+	errs := validateYAML(t, `content:
+  achievements:
+    speedrun:
+      name: Speedrun
+      reqs:
+        - "[stat:fastest_prestige] < 600"
+        - "[stat:prestiges] >= 1"
+        - "[run:prestiges] >= 1"
+  prestige:
+    prestige:
+      name: Prestige
+      tier: 1
+`)
+	findError(t, errs, "content.achievements.speedrun.reqs[2]")
+	if len(errs) != 1 {
+		t.Errorf("expected only run:prestiges to be rejected, got %v", errs)
+	}
+}